@@ -0,0 +1,10 @@
+// Package api holds schemas of order messages accepted by consumer.
+// Go code for order.proto is generated into orderpb by `task generate`.
+package api
+
+import _ "embed"
+
+// OrderAvroSchema is Avro schema of order, used as reader schema for messages in any compatible writer schema.
+//
+//go:embed order.avsc
+var OrderAvroSchema string
@@ -39,5 +39,9 @@ func main() {
 		application.KafkaApp.Run(ctx)
 	}()
 
+	go func() {
+		application.OrderChangesListener.Run(ctx)
+	}()
+
 	shutdown.WaitSignal(makeQuitSignal())
 }
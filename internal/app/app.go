@@ -2,31 +2,50 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
 	"time"
 
 	"wb-L0-task/internal/app/http"
 	"wb-L0-task/internal/app/kafka"
+	consumer_controller "wb-L0-task/internal/controllers/consumer"
 	order_controller "wb-L0-task/internal/controllers/order"
+	"wb-L0-task/internal/decoders"
 	"wb-L0-task/internal/domain/order"
 	order_service "wb-L0-task/internal/domain/services/order"
+	"wb-L0-task/internal/pkg/backoff"
 	"wb-L0-task/internal/pkg/cache"
 	"wb-L0-task/internal/pkg/config"
 	kafka_pkg "wb-L0-task/internal/pkg/kafka"
 	"wb-L0-task/internal/pkg/logger"
+	"wb-L0-task/internal/pkg/metrics"
 	"wb-L0-task/internal/pkg/postgres"
+	"wb-L0-task/internal/pkg/schemaregistry"
 	"wb-L0-task/internal/pkg/shutdown"
 	repo_pkg "wb-L0-task/internal/repositories/postgres"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	kafka_go "github.com/segmentio/kafka-go"
 )
 
 type App struct {
-	HTTPApp  *http.App
-	KafkaApp *kafka.App
+	HTTPApp              *http.App
+	KafkaApp             *kafka.Consumers
+	OrderChangesListener *postgres.Listener
 }
 
 func New(
 	ctx context.Context,
 	cfg *config.AppConfig,
 ) *App {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	consumerMetrics := metrics.NewConsumer(registry)
+
 	pool, trManager, ctxGetter, err := postgres.SetupPostgres(ctx, cfg.Postgres)
 	if err != nil {
 		logger.Error("failed to setup postgres", "err", err)
@@ -34,33 +53,225 @@ func New(
 
 	orderRepo := repo_pkg.NewOrder(pool, trManager, ctxGetter)
 
-	ordersCache := cache.NewCache[order.Order](cfg.Cache)
-	orderService := order_service.New(ordersCache, orderRepo)
-	err = orderService.InitCache(ctx)
-	if err != nil {
-		logger.Error("Failed to init cache", "err", err)
+	ordersCache := newCache[order.Order](cfg.Cache)
+	// Snapshot is restored synchronously, so HTTP server starts with warm cache
+	if cfg.Cache.SnapshotPath != "" {
+		restoreSnapshot(cfg.Cache.SnapshotPath, ordersCache)
 	}
 
-	orderController := order_controller.New(orderService)
+	var negativeCache managedCache[struct{}]
+	if cfg.Cache.NegativeExpirationTime > 0 {
+		negativeConfig := *cfg.Cache
+		negativeConfig.DefaultExpirationTime = cfg.Cache.NegativeExpirationTime
+		negativeConfig.StaleGraceTime = 0
+		negativeCache = newCache[struct{}](&negativeConfig)
+	}
 
-	consumer := kafka_pkg.NewConsumer(cfg.Kafka)
+	orderService := order_service.New(ordersCache, negativeCache, orderRepo)
+	// Warm-up runs in background, so startup is not blocked by storage
+	go func() {
+		err := orderService.InitCache(ctx, order_service.WarmUp{
+			Strategy:   order_service.WarmUpStrategy(cfg.Cache.WarmUp.Strategy),
+			Size:       cfg.Cache.WarmUp.Size,
+			Window:     time.Duration(cfg.Cache.WarmUp.WindowHours) * time.Hour,
+			BatchSize:  cfg.Cache.WarmUp.BatchSize,
+			Expiration: time.Duration(cfg.Cache.WarmUp.ExpirationTime) * time.Second,
+		})
+		if err != nil {
+			logger.Error("Failed to init cache", "err", err)
+		}
+	}()
 
-	httpApp := http.New(cfg, orderController)
+	// Orders changed by other replicas are refreshed in local cache.
+	// Changes missed while listener is disconnected are dropped from cache after reconnect
+	orderChangesListener := postgres.NewListener(pool, repo_pkg.OrderChangesChannel,
+		orderChangesHandler(orderService, orderRepo.Origin(), cfg.Cache.WriteThrough), orderService.InvalidateAll)
 
-	kafkaConsumerService := order_service.NewKafkaConsumerService(orderRepo)
-	kafkaApp := kafka.New(consumer, kafkaConsumerService)
+	// Consumer shares cache with HTTP side, so fresh orders are served without DB hit
+	var consumerCache order_service.Cache[order.Order]
+	if cfg.Cache.WriteThrough {
+		consumerCache = ordersCache
+	}
+	// Config is validated on load, so unknown policy is a bug
+	conflictPolicy := order_service.ConflictPolicy(cfg.Kafka.Consumer.ConflictPolicy)
+	if conflictPolicy != "" {
+		if err = conflictPolicy.Validate(); err != nil {
+			log.Fatal("invalid conflict policy: ", err)
+		}
+	}
+	kafkaConsumerService := order_service.NewKafkaConsumerService(orderRepo, order_service.KafkaConsumerOptions{
+		Cache:    consumerCache,
+		Negative: negativeCache,
+		Conflict: conflictPolicy,
+		Decoders: newDecoders(cfg.SchemaRegistry),
+		Metrics:  consumerMetrics,
+	})
+	// Config is validated on load, so dialer fails only if certificate files are changed since then
+	dialer, err := kafka_pkg.NewDialer(cfg.Kafka)
+	if err != nil {
+		log.Fatal("failed to create kafka dialer: ", err)
+	}
+	var deadLetter kafka.DeadLetterWriter
+	if cfg.Kafka.Topics.DeadLetter != "" {
+		deadLetter = kafka_pkg.NewDeadLetterProducer(cfg.Kafka, dialer)
+	}
+	handlers := kafka.TopicHandlers{
+		cfg.Kafka.Topics.Orders.Name:               kafka.NewOrderEvents(kafkaConsumerService),
+		cfg.Kafka.Topics.OrderUpdates.Name:         kafka.NewOrderUpdates(kafkaConsumerService),
+		cfg.Kafka.Topics.PaymentConfirmations.Name: kafka.NewPaymentConfirmations(kafkaConsumerService),
+	}
+	retryCfg := cfg.Kafka.Consumer.Retry
+	retryPolicy := kafka.RetryPolicy{
+		Backoff: backoff.Backoff{
+			Initial:    time.Duration(retryCfg.InitialInterval) * time.Millisecond,
+			Max:        time.Duration(retryCfg.MaxInterval) * time.Millisecond,
+			Multiplier: retryCfg.Multiplier,
+			Jitter:     retryCfg.Jitter,
+		},
+		MaxRetries: retryCfg.MaxRetries,
+	}
+	batchPolicy := kafka.BatchPolicy{
+		Size:    cfg.Kafka.Consumer.Batch.Size,
+		Timeout: time.Duration(cfg.Kafka.Consumer.Batch.Timeout) * time.Millisecond,
+	}
+	// Every topic is read by its own consumer group, apps of all topics are shut down together
+	var consumerApps []*kafka.App
+	for _, topic := range cfg.Kafka.InputTopics() {
+		consumerOffsets := repo_pkg.NewConsumerOffsets(pool, trManager, ctxGetter, topic.GroupID)
+		consumer, offsetStorage, err := newConsumer(cfg.Kafka, topic, dialer, consumerOffsets, trManager)
+		if err != nil {
+			log.Fatal("failed to create kafka consumer: ", err)
+		}
+		consumerApps = append(consumerApps, kafka.New(topic.Name, consumer, handlers, kafka.Options{
+			DeadLetter: deadLetter,
+			Retry:      retryPolicy,
+			Batch:      batchPolicy,
+			Workers:    topic.Workers,
+			Offsets:    offsetStorage,
+			Metrics:    consumerMetrics,
+		}))
+	}
+	kafkaApp := kafka.NewConsumers(deadLetter, consumerApps...)
+
+	orderController := order_controller.New(orderService)
+	consumerController := consumer_controller.New(kafkaApp)
+
+	httpApp := http.New(cfg, orderController, consumerController, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 
 	//nolint:contextcheck
 	shutdown.RegisterFn(func() {
 		logger.Info("Shutting down")
-		pool.Close()
+		orderChangesListener.Shutdown()
 		httpApp.Shutdown(time.Duration(cfg.Server.ShutdownTimeout))
+		// Consumer drains messages in progress, so pool is closed after it
 		kafkaApp.Shutdown()
+		pool.Close()
 		logger.Info("Shutdown completed")
 	})
+	// Registered after main shutdown, so snapshot includes orders saved by consumer until it stopped
+	if cfg.Cache.SnapshotPath != "" {
+		shutdown.RegisterFn(func() {
+			if err := cache.SaveSnapshot(cfg.Cache.SnapshotPath, ordersCache); err != nil {
+				logger.Error("Failed to save cache snapshot", "path", cfg.Cache.SnapshotPath, "err", err)
+				return
+			}
+			logger.Info("Cache snapshot saved", "path", cfg.Cache.SnapshotPath)
+		})
+	}
+	shutdown.RegisterFn(ordersCache.Close)
+	if negativeCache != nil {
+		shutdown.RegisterFn(negativeCache.Close)
+	}
 
 	return &App{
-		HTTPApp:  httpApp,
-		KafkaApp: kafkaApp,
+		HTTPApp:              httpApp,
+		KafkaApp:             kafkaApp,
+		OrderChangesListener: orderChangesListener,
+	}
+}
+
+// newConsumer creates message source of topic according to configured offset storage.
+// If offsets are stored in postgres, returned offset storage is not nil.
+// Consumer with stored offsets is never replaced with Kafka one, as it would break exactly once processing.
+func newConsumer(
+	cfg *kafka_pkg.Config,
+	topic kafka_pkg.TopicConfig,
+	dialer *kafka_go.Dialer,
+	offsets *repo_pkg.ConsumerOffsets,
+	trManager kafka.TrManager,
+) (kafka.MessageSource, *kafka.OffsetStorage, error) { //nolint:ireturn
+	storage := kafka_pkg.OffsetStorage(cfg.Consumer.OffsetStorage)
+	if err := storage.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if storage != kafka_pkg.OffsetStoragePostgres {
+		return kafka_pkg.NewConsumer(cfg, topic, dialer), nil, nil
+	}
+
+	consumer, err := kafka_pkg.NewStoredOffsetConsumer(cfg, topic, dialer, offsets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create consumer of topic %q with stored offsets: %w", topic.Name, err)
+	}
+	logger.Info("Offsets are stored in postgres", "topic", topic.Name, "group_id", topic.GroupID)
+	return consumer, &kafka.OffsetStorage{TrManager: trManager, Store: offsets}, nil
+}
+
+// orderChangesHandler refreshes cached orders changed by notification payload.
+// If cache is written through, own changes are already cached, so they only clear negative cache entry
+// instead of reloading order.
+func orderChangesHandler(
+	service *order_service.Order,
+	origin string,
+	writeThrough bool,
+) func(ctx context.Context, payload string) {
+	return func(ctx context.Context, payload string) {
+		change := repo_pkg.ParseOrderChange(payload)
+		if writeThrough && change.Origin == origin {
+			service.InvalidateUnknown(ctx, change.UID)
+			return
+		}
+		service.Invalidate(ctx, change.UID)
+	}
+}
+
+type managedCache[T any] interface {
+	order_service.Cache[T]
+	cache.Snapshotter
+	Close()
+}
+
+func newCache[T any](cfg *cache.Config) managedCache[T] { //nolint:ireturn
+	if cfg.Shards > 1 {
+		return cache.NewShardedCache[T](cfg)
+	}
+	return cache.NewCache[T](cfg)
+}
+
+func restoreSnapshot(path string, c cache.Snapshotter) {
+	restored, err := cache.LoadSnapshot(path, c)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		logger.Info("Cache snapshot not found, start with empty cache", "path", path)
+	case err != nil:
+		logger.Warn("Failed to restore cache snapshot, start with empty cache", "path", path, "err", err)
+	default:
+		logger.Info("Cache restored from snapshot", "path", path, "items", restored)
+	}
+}
+
+// newDecoders registers decoders of order content types. Avro requires schema registry,
+// so without registry URL Avro messages are rejected as unknown content type.
+func newDecoders(cfg *schemaregistry.Config) *order_service.Decoders {
+	res := order_service.NewDecoders()
+	res.Register(order_service.ContentTypeProtobuf, decoders.Protobuf{})
+	if cfg == nil || cfg.URL == "" {
+		return res
+	}
+	avroDecoder, err := decoders.NewAvro(schemaregistry.New(cfg))
+	if err != nil {
+		logger.Error("Failed to create avro decoder, avro orders are rejected", "err", err)
+		return res
 	}
+	res.Register(order_service.ContentTypeAvro, avroDecoder)
+	return res
 }
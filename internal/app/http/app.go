@@ -2,12 +2,14 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"wb-L0-task/internal/controllers/consumer"
 	"wb-L0-task/internal/controllers/order"
 	"wb-L0-task/internal/pkg/config"
 	"wb-L0-task/internal/pkg/logger"
@@ -24,9 +26,13 @@ type App struct {
 	controller *order.Controller
 }
 
+// New creates HTTP app. Admin API of consumers is served only if admin token is configured.
+// Metrics are served at /metrics.
 func New(
 	config *config.AppConfig,
 	controller *order.Controller,
+	consumerController *consumer.Controller,
+	metrics http.Handler,
 ) *App {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
@@ -36,12 +42,19 @@ func New(
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Warning", "X-Cache"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("./web"))))
 	registerRoutes(r, controller)
+	r.Handle("/metrics", metrics)
+	if config.Server.AdminToken != "" {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(adminAuth(config.Server.AdminToken))
+			registerAdminRoutes(r, consumerController)
+		})
+	}
 
 	s := server.New(config.Server)
 	s.Handler = r
@@ -79,3 +92,25 @@ func (a *App) Shutdown(shutdownTimeout time.Duration) {
 func registerRoutes(router *chi.Mux, controller *order.Controller) {
 	router.Get("/order/{order_uid}", controller.GetOrderById())
 }
+
+func registerAdminRoutes(router chi.Router, controller *consumer.Controller) {
+	router.Get("/consumers", controller.States())
+	router.Get("/consumers/{topic}", controller.State())
+	router.Post("/consumers/{topic}/pause", controller.Pause())
+	router.Post("/consumers/{topic}/resume", controller.Resume())
+	router.Post("/consumers/{topic}/seek", controller.Seek())
+}
+
+// adminAuth allows requests with bearer token equal to admin token.
+func adminAuth(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -3,62 +3,462 @@ package kafka
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"sync"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
 	"wb-L0-task/internal/domain/services/order"
 	"wb-L0-task/internal/pkg/logger"
 
 	"github.com/segmentio/kafka-go"
 )
 
+// workerQueueSize is a number of messages fetched ahead for every worker.
+const workerQueueSize = 64
+
+// errNotProcessed means messages are neither processed nor dead lettered, so their offsets must not be committed.
+var errNotProcessed = errors.New("messages are not processed")
+
+// fetchFunc returns next message. It blocks until message is available or ctx is done.
+type fetchFunc func(ctx context.Context) (kafka.Message, error)
+
+// MessageSource reads messages of consumed topics and commits offsets of processed ones.
+// Kafka is read by readers of pkg/kafka, MemorySource runs app without broker.
+// FetchMessage returns io.EOF when source is closed or has no more messages.
+type MessageSource interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 type App struct {
-	consumer *kafka.Reader
-	service  *order.KafkaConsumerService
+	topic      string
+	consumer   MessageSource
+	deadLetter DeadLetterWriter
+	handlers   HandlerRegistry
+	retry      RetryPolicy
+	batch      BatchPolicy
+	workers    int
+	offsets    *OffsetStorage
+	metrics    Metrics
+	// lagInterval is a period of lag reports.
+	lagInterval time.Duration
+	control     control
+	stop        chan struct{}
+	done        chan struct{}
 }
 
-func New(consumer *kafka.Reader, service *order.KafkaConsumerService) *App {
+// Options are optional settings of App.
+type Options struct {
+	// DeadLetter, if set, receives rejected messages before their offsets are committed.
+	DeadLetter DeadLetterWriter
+	// Retry tells how transient failures are retried before offset is committed.
+	// Without DeadLetter retries are not limited, as message failing after all of them would be lost.
+	Retry RetryPolicy
+	// Batch, if its size is greater than 1, makes messages saved and committed in batches.
+	Batch BatchPolicy
+	// Workers, if greater than 1, processes partitions in parallel, keeping order within a partition.
+	Workers int
+	// Offsets, if set, stores offsets in database together with processing results.
+	Offsets *OffsetStorage
+	// Metrics, if set, observes outcome and latency of committed messages,
+	// and lag of consumer group, if consumer is a LagReader.
+	Metrics Metrics
+	// LagInterval is a period of lag reports. Default is 15 seconds.
+	LagInterval time.Duration
+}
+
+// New creates Kafka consumer app of topic, which passes messages to handlers of their topics.
+func New(topic string, consumer MessageSource, handlers HandlerRegistry, opts Options) *App {
+	if opts.DeadLetter == nil && opts.Retry.MaxRetries > 0 {
+		logger.Warn("Dead letter topic is not set, failing messages are retried until shutdown",
+			"topic", topic, "max_retries", opts.Retry.MaxRetries)
+		opts.Retry.MaxRetries = 0
+	}
+	if opts.LagInterval <= 0 {
+		opts.LagInterval = defaultLagInterval
+	}
 	return &App{
-		consumer: consumer,
-		service:  service,
+		topic:       topic,
+		consumer:    consumer,
+		deadLetter:  opts.DeadLetter,
+		handlers:    handlers,
+		retry:       opts.Retry,
+		batch:       opts.Batch,
+		workers:     opts.Workers,
+		offsets:     opts.Offsets,
+		metrics:     opts.Metrics,
+		lagInterval: opts.LagInterval,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 }
 
+// Run consumes messages until ctx is done or Shutdown is called.
 func (a *App) Run(ctx context.Context) {
-	logger.Info("Starting Kafka consumer...")
+	defer close(a.done)
+	a.control.setRunning(true)
+	defer a.control.setRunning(false)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-a.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	if lagReader, ok := a.consumer.(LagReader); ok && a.metrics != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.reportLag(ctx, lagReader)
+		}()
+	}
+
+	logger.Info("Starting Kafka consumer...", "topic", a.topic, "workers", a.workers, "batch_size", a.batch.Size)
+	if a.workers > 1 {
+		a.runWorkers(ctx, cancel)
+	} else {
+		a.consume(ctx, cancel, a.fetchMessage)
+	}
+	cancel()
+	wg.Wait()
+	logger.Info("Kafka consumer stopped", "topic", a.topic)
+}
+
+// runWorkers dispatches messages to workers by partition.
+// Every partition is processed by a single worker, so its messages are saved and committed in order,
+// and commit of a message never covers a message which is not done yet.
+func (a *App) runWorkers(ctx context.Context, stop context.CancelFunc) {
+	queues := make([]chan kafka.Message, a.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			a.consume(ctx, stop, queueFetch(queue))
+		}(queues[i])
+	}
+
+	for {
+		msg, err := a.fetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				break
+			}
+			if !errors.Is(err, errPaused) {
+				logger.Error("Error while reading message", "err", err)
+				a.control.failed(err)
+			}
+			continue
+		}
+		select {
+		case queues[msg.Partition%len(queues)] <- msg:
+		case <-ctx.Done():
+		}
+	}
+
+	// Queued messages are not committed, so they are redelivered after restart
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// queueFetch returns fetchFunc reading messages dispatched to worker.
+func queueFetch(queue <-chan kafka.Message) fetchFunc {
+	return func(ctx context.Context) (kafka.Message, error) {
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case msg, ok := <-queue:
+			if !ok {
+				return kafka.Message{}, io.EOF
+			}
+			return msg, nil
+		}
+	}
+}
+
+// consume processes messages from fetch until ctx is done or fetch is closed.
+// If messages can't be processed nor dead lettered, consumer is stopped by stop, so they are redelivered after restart.
+func (a *App) consume(ctx context.Context, stop context.CancelFunc, fetch fetchFunc) {
 	for {
-		if errors.Is(ctx.Err(), context.Canceled) {
+		if ctx.Err() != nil {
 			return
 		}
-		msg, err := a.consumer.FetchMessage(ctx)
+		var err error
+		if a.batch.Size > 1 {
+			err = a.consumeBatch(ctx, fetch)
+		} else {
+			err = a.consumeOne(ctx, fetch)
+		}
 		if err != nil {
-			if errors.Is(err, io.EOF) {
+			// Offset is not committed on shutdown, so message is redelivered after restart
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, errNotProcessed) {
+				logger.Error("Messages are not processed, stop consumer", "topic", a.topic, "err", err)
+				a.control.failed(err)
+				stop()
 				return
 			}
-			logger.Error("Error while reading message", "err", err)
+			if !errors.Is(err, errPaused) {
+				logger.Error("Error while reading message", "err", err)
+				a.control.failed(err)
+			}
+		}
+	}
+}
+
+// consumeOne fetches, saves and commits one message.
+// Message fetched before shutdown is finished, only waiting for retry is interrupted.
+func (a *App) consumeOne(ctx context.Context, fetch fetchFunc) error {
+	msg, err := fetch(ctx)
+	if err != nil {
+		return err
+	}
+	logReceived(msg)
+
+	start := time.Now()
+	outcomes, err := a.process(ctx, []kafka.Message{msg}, a.handleEach)
+	if err != nil {
+		return err
+	}
+	a.commit(ctx, msg)
+	a.observe([]kafka.Message{msg}, outcomes, start)
+	logger.Debug("Message processed", "partition", msg.Partition, "offset", msg.Offset, "result", outcomes[0].Result)
+	return nil
+}
+
+// consumeBatch fetches batch of messages, saves valid orders in one transaction and commits all offsets together.
+// Messages which failed individually are rejected without failing the whole batch.
+func (a *App) consumeBatch(ctx context.Context, fetch fetchFunc) error {
+	msgs, err := fetchBatch(ctx, fetch, a.batch)
+	if len(msgs) == 0 {
+		return err
+	}
+	if err != nil && ctx.Err() == nil && !errors.Is(err, errPaused) {
+		logger.Error("Error while reading batch, process fetched messages", "err", err, "size", len(msgs))
+		a.control.failed(err)
+	}
+	for _, msg := range msgs {
+		logReceived(msg)
+	}
+
+	start := time.Now()
+	outcomes, err := a.process(ctx, msgs, a.handleBatch)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[order.SaveResult]int)
+	for _, outcome := range outcomes {
+		counts[outcome.Result]++
+	}
+	a.commit(ctx, msgs...)
+	a.observe(msgs, outcomes, start)
+	logger.Info("Batch processed",
+		"size", len(msgs),
+		"created", counts[order.SaveCreated],
+		"updated", counts[order.SaveUpdated],
+		"duplicate", counts[order.SaveDuplicate],
+		"outdated", counts[order.SaveOutdated],
+		"deleted", counts[order.SaveDeleted],
+		"failed", counts[order.SaveFailed],
+	)
+	return nil
+}
+
+// process runs fn for msgs, retrying transient failures, and returns outcome of every message.
+// If msgs are still failing after all retries, every message fails with the last error.
+// Failed messages are dead lettered once, after transaction storing their offsets is committed,
+// so retried transaction doesn't dead letter them again.
+// Processing is not interrupted by shutdown, only waiting for retry is, then ctx error is returned.
+// Error wrapping errNotProcessed means offsets of msgs must not be committed, so consumer is stopped.
+func (a *App) process(ctx context.Context, msgs []kafka.Message, fn processFunc) ([]order.SaveOutcome, error) {
+	outcomes, err := a.processRetrying(ctx, a.retry, msgs, fn)
+	if err != nil && ctx.Err() == nil {
+		// Offsets of failed messages are stored, so it is retried until storage is back
+		outcomes, err = a.processRetrying(ctx, RetryPolicy{Backoff: a.retry.Backoff}, msgs, failAll(err))
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: store offsets of failed messages: %w", errNotProcessed, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = a.rejectFailed(ctx, msgs, outcomes); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// processRetrying runs fn for msgs in transaction, retrying transient failures according to policy.
+// Only ctx error is returned if ctx is done.
+func (a *App) processRetrying(
+	ctx context.Context,
+	policy RetryPolicy,
+	msgs []kafka.Message,
+	fn processFunc,
+) ([]order.SaveOutcome, error) {
+	var outcomes []order.SaveOutcome
+	err := retry(ctx, policy, func(ctx context.Context) error {
+		var processErr error
+		outcomes, processErr = a.transaction(context.WithoutCancel(ctx), msgs, fn)
+		return processErr
+	})
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return outcomes, err
+}
+
+// failAll returns fn which fails every message with err.
+func failAll(err error) processFunc {
+	return func(_ context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+		outcomes := make([]order.SaveOutcome, len(msgs))
+		for i := range outcomes {
+			outcomes[i] = order.SaveOutcome{Result: order.SaveFailed, Err: err}
+		}
+		return outcomes, nil
+	}
+}
+
+// handle passes message to handler of its topic. Message of topic without handler is rejected.
+func (a *App) handle(ctx context.Context, msg kafka.Message) (order.SaveResult, error) {
+	handler, ok := a.handlers.Handler(msg.Topic)
+	if !ok {
+		return order.SaveFailed, serviceErrors.ErrBrokenEntity.ForEntity("topic")
+	}
+	return handler.Handle(ctx, msg)
+}
+
+// handleEach handles messages one by one.
+func (a *App) handleEach(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+	return handleEach(ctx, a.handle, msgs)
+}
+
+// handleBatch passes messages to batch handler of their topic, or handles them one by one,
+// if topic handler doesn't support batches or messages are of different topics.
+func (a *App) handleBatch(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+	topic := msgs[0].Topic
+	for _, msg := range msgs[1:] {
+		if msg.Topic != topic {
+			return a.handleEach(ctx, msgs)
+		}
+	}
+	handler, ok := a.handlers.Handler(topic)
+	batchHandler, isBatch := handler.(BatchHandler)
+	if !ok || !isBatch {
+		return a.handleEach(ctx, msgs)
+	}
+	return batchHandler.HandleBatch(ctx, msgs)
+}
+
+// rejectFailed handles messages which are failed to be saved. Every failed message is dead lettered, as its offset
+// is committed: rejected message can't be saved at all, and other ones are still failing after all retries.
+func (a *App) rejectFailed(ctx context.Context, msgs []kafka.Message, outcomes []order.SaveOutcome) error {
+	var failed []int
+	for i, msg := range msgs {
+		err := outcomes[i].Err
+		if err == nil {
 			continue
 		}
-		logger.Info("Message received",
-			"topic", msg.Topic,
-			"partition", msg.Partition,
-			"offset", msg.Offset,
-			"Key", string(msg.Key),
-			"Value", string(msg.Value),
-		)
-		logger.Info("Received message", "kafka msg", string(msg.Value))
-		err = a.service.SaveOrder(ctx, msg.Value)
-		if err != nil {
-			logger.Error("Failed to save order", "err", err)
+		if isRejected(err) {
+			logger.Warn("Message is rejected", "err", err, "partition", msg.Partition, "offset", msg.Offset)
+		} else {
+			logger.Error("Failed to process message", "err", err, "partition", msg.Partition, "offset", msg.Offset)
 		}
-		if err = a.consumer.CommitMessages(ctx, msg); err != nil {
-			log.Fatal("failed to commit messages:", err)
+		a.control.failed(err)
+		failed = append(failed, i)
+	}
+	if len(failed) == 0 || a.deadLetter == nil {
+		return nil
+	}
+
+	deadLetters := make([]kafka.Message, len(failed))
+	failedAt := time.Now()
+	for j, i := range failed {
+		deadLetters[j] = deadLetterMessage(msgs[i], outcomes[i].Err, failedAt)
+	}
+	if err := a.sendToDeadLetter(ctx, deadLetters); err != nil {
+		return err
+	}
+	for _, i := range failed {
+		logger.Info("Message sent to dead letter topic",
+			"topic", msgs[i].Topic,
+			"partition", msgs[i].Partition,
+			"offset", msgs[i].Offset,
+			"reason", outcomes[i].Err,
+		)
+	}
+	return nil
+}
+
+// commit commits offsets of processed messages. It is not interrupted by shutdown.
+func (a *App) commit(ctx context.Context, msgs ...kafka.Message) {
+	if err := a.consumer.CommitMessages(context.WithoutCancel(ctx), msgs...); err != nil {
+		log.Fatal("failed to commit messages:", err)
+	}
+	a.control.committed(msgs)
+}
+
+func logReceived(msg kafka.Message) {
+	logger.Info("Message received",
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+		"Key", string(msg.Key),
+		"Value", string(msg.Value),
+	)
+}
+
+// sendToDeadLetter writes messages to dead letter topic, retrying failed writes until ctx is done.
+// Offsets must not be committed if messages are not forwarded, so errNotProcessed is returned then.
+func (a *App) sendToDeadLetter(ctx context.Context, deadLetters []kafka.Message) error {
+	err := retry(ctx, RetryPolicy{Backoff: a.retry.Backoff}, func(ctx context.Context) error {
+		if err := a.deadLetter.WriteMessages(context.WithoutCancel(ctx), deadLetters...); err != nil {
+			return fmt.Errorf("%w: %w", serviceErrors.ErrUnavailable.ForEntity("dead letter topic"), err)
 		}
-		continue
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: write to dead letter topic: %w", errNotProcessed, err)
 	}
+	return nil
 }
 
+// Shutdown stops fetching, waits until messages in progress are saved and committed, then closes consumer
+// and dead letter writer.
 func (a *App) Shutdown() {
+	a.stopConsuming()
+	closeDeadLetter(a.deadLetter)
+}
+
+// stopConsuming stops fetching, waits until messages in progress are saved and committed, then closes consumer.
+func (a *App) stopConsuming() {
 	logger.Info("Shutting down Kafka consumer")
+	close(a.stop)
+	<-a.done
 	if err := a.consumer.Close(); err != nil {
 		logger.Error("Failed to close consumer", "err", err)
 	}
 }
+
+func closeDeadLetter(deadLetter DeadLetterWriter) {
+	if deadLetter == nil {
+		return
+	}
+	if err := deadLetter.Close(); err != nil {
+		logger.Error("Failed to close dead letter producer", "err", err)
+	}
+}
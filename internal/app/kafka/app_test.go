@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueFetch(t *testing.T) {
+	queue := make(chan kafka.Message, 2)
+	queue <- kafka.Message{Offset: 1}
+	queue <- kafka.Message{Offset: 2}
+	close(queue)
+	fetch := queueFetch(queue)
+
+	// Queued messages are returned in order before queue is reported closed
+	msg, err := fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), msg.Offset)
+	msg, err = fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), msg.Offset)
+	_, err = fetch(context.Background())
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestQueueFetch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := queueFetch(make(chan kafka.Message))(ctx)
+
+	require.ErrorIs(t, err, context.Canceled)
+}
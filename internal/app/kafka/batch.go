@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// BatchPolicy describes how messages are grouped into batches.
+type BatchPolicy struct {
+	// Size is a maximum number of messages in batch. Values <= 1 disable batching.
+	Size int
+	// Timeout limits time batch is collected after its first message is fetched.
+	Timeout time.Duration
+}
+
+// fetchBatch fetches up to policy.Size messages.
+// It waits for the first message as long as needed and for the rest of batch no longer than policy.Timeout,
+// so slow topic does not delay fetched messages. Messages fetched before error are returned with it.
+func fetchBatch(
+	ctx context.Context,
+	fetch func(ctx context.Context) (kafka.Message, error),
+	policy BatchPolicy,
+) ([]kafka.Message, error) {
+	first, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]kafka.Message, 0, policy.Size)
+	msgs = append(msgs, first)
+
+	batchCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+	defer cancel()
+	for len(msgs) < policy.Size {
+		msg, err := fetch(batchCtx)
+		if err != nil {
+			// Batch window is over, but app is still running
+			if batchCtx.Err() != nil && ctx.Err() == nil {
+				return msgs, nil
+			}
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetch returns messages from channel, blocking like kafka.Reader.FetchMessage when it is empty.
+func fakeFetch(msgs <-chan kafka.Message) func(ctx context.Context) (kafka.Message, error) {
+	return func(ctx context.Context) (kafka.Message, error) {
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case msg := <-msgs:
+			return msg, nil
+		}
+	}
+}
+
+func TestFetchBatch_FullBatch(t *testing.T) {
+	msgs := make(chan kafka.Message, 5)
+	for i := range 5 {
+		msgs <- kafka.Message{Offset: int64(i)}
+	}
+
+	batch, err := fetchBatch(context.Background(), fakeFetch(msgs), BatchPolicy{Size: 3, Timeout: time.Hour})
+
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+	assert.Equal(t, int64(2), batch[2].Offset)
+	assert.Len(t, msgs, 2)
+}
+
+func TestFetchBatch_Timeout(t *testing.T) {
+	msgs := make(chan kafka.Message, 2)
+	msgs <- kafka.Message{Offset: 1}
+	msgs <- kafka.Message{Offset: 2}
+
+	start := time.Now()
+	batch, err := fetchBatch(context.Background(), fakeFetch(msgs), BatchPolicy{
+		Size:    10,
+		Timeout: 20 * time.Millisecond,
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestFetchBatch_ErrorAfterFetched(t *testing.T) {
+	msgs := make(chan kafka.Message, 1)
+	msgs <- kafka.Message{Offset: 1}
+	fetchMsg := fakeFetch(msgs)
+	calls := 0
+
+	batch, err := fetchBatch(context.Background(), func(ctx context.Context) (kafka.Message, error) {
+		calls++
+		if calls > 1 {
+			return kafka.Message{}, io.EOF
+		}
+		return fetchMsg(ctx)
+	}, BatchPolicy{Size: 10, Timeout: time.Hour})
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Len(t, batch, 1)
+}
+
+func TestFetchBatch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	batch, err := fetchBatch(ctx, fakeFetch(nil), BatchPolicy{Size: 10, Timeout: time.Hour})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, batch)
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+)
+
+// Consumers runs apps consuming input topics, every topic with its own consumer group,
+// and shuts them down together. Dead letter writer is shared by apps, so it is closed once.
+type Consumers struct {
+	apps       []*App
+	deadLetter DeadLetterWriter
+}
+
+func NewConsumers(deadLetter DeadLetterWriter, apps ...*App) *Consumers {
+	return &Consumers{apps: apps, deadLetter: deadLetter}
+}
+
+// Run consumes all topics until ctx is done or Shutdown is called.
+func (c *Consumers) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, app := range c.apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.Run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Shutdown stops all apps at once, so messages in progress of every topic are drained in parallel,
+// then closes dead letter writer.
+func (c *Consumers) Shutdown() {
+	var wg sync.WaitGroup
+	for _, app := range c.apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.stopConsuming()
+		}()
+	}
+	wg.Wait()
+	closeDeadLetter(c.deadLetter)
+}
+
+// States returns states of consumers of all topics.
+func (c *Consumers) States() []kafka_pkg.State {
+	res := make([]kafka_pkg.State, len(c.apps))
+	for i, app := range c.apps {
+		res[i] = app.State()
+	}
+	return res
+}
+
+// State returns state of consumer of topic.
+func (c *Consumers) State(topic string) (kafka_pkg.State, error) {
+	app, err := c.app(topic)
+	if err != nil {
+		return kafka_pkg.State{}, err
+	}
+	return app.State(), nil
+}
+
+// Pause stops fetching messages of topic.
+func (c *Consumers) Pause(topic string) (kafka_pkg.State, error) {
+	app, err := c.app(topic)
+	if err != nil {
+		return kafka_pkg.State{}, err
+	}
+	return app.Pause(), nil
+}
+
+// Resume continues fetching messages of topic.
+func (c *Consumers) Resume(topic string) (kafka_pkg.State, error) {
+	app, err := c.app(topic)
+	if err != nil {
+		return kafka_pkg.State{}, err
+	}
+	return app.Resume(), nil
+}
+
+// Seek moves consumer group of topic to position. Consumer of topic must be paused and idle.
+func (c *Consumers) Seek(ctx context.Context, topic string, pos kafka_pkg.SeekPosition) (map[int]int64, error) {
+	app, err := c.app(topic)
+	if err != nil {
+		return nil, err
+	}
+	return app.Seek(ctx, pos)
+}
+
+func (c *Consumers) app(topic string) (*App, error) {
+	for _, app := range c.apps {
+		if app.topic == topic {
+			return app, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", kafka_pkg.ErrUnknownTopic, topic)
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idleSource has no messages, FetchMessage blocks until ctx is done.
+type idleSource struct {
+	closed atomic.Int32
+}
+
+func (s *idleSource) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (s *idleSource) CommitMessages(context.Context, ...kafka.Message) error { return nil }
+
+func (s *idleSource) Close() error {
+	s.closed.Add(1)
+	return nil
+}
+
+type countingDeadLetter struct {
+	closed atomic.Int32
+}
+
+func (d *countingDeadLetter) WriteMessages(context.Context, ...kafka.Message) error { return nil }
+
+func (d *countingDeadLetter) Close() error {
+	d.closed.Add(1)
+	return nil
+}
+
+func TestConsumers_Shutdown(t *testing.T) {
+	orders, updates := &idleSource{}, &idleSource{}
+	deadLetter := &countingDeadLetter{}
+	consumers := NewConsumers(deadLetter,
+		New("orders", orders, TopicHandlers{}, Options{DeadLetter: deadLetter}),
+		New("order-updates", updates, TopicHandlers{}, Options{DeadLetter: deadLetter, Workers: 2}),
+	)
+	done := make(chan struct{})
+	go func() {
+		consumers.Run(context.Background())
+		close(done)
+	}()
+
+	consumers.Shutdown()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("consumers are still running after shutdown")
+	}
+	assert.Equal(t, int32(1), orders.closed.Load())
+	assert.Equal(t, int32(1), updates.closed.Load())
+	assert.Equal(t, int32(1), deadLetter.closed.Load())
+}
+
+func TestConsumers_UnknownTopic(t *testing.T) {
+	consumers := NewConsumers(nil,
+		New("orders", &idleSource{}, TopicHandlers{}, Options{}),
+	)
+
+	state, err := consumers.Pause("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "orders", state.Topic)
+
+	_, err = consumers.Pause("payments")
+	require.ErrorIs(t, err, kafka_pkg.ErrUnknownTopic)
+	assert.Len(t, consumers.States(), 1)
+}
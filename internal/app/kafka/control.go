@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// errPaused is returned by fetch interrupted by Pause. Nothing is fetched, so it is not an error of consumer.
+var errPaused = errors.New("consumer is paused")
+
+// Seeker is implemented by message source which can move its consumer group to another position.
+type Seeker interface {
+	// Seek moves consumer group to position and returns offsets of the next messages to consume by partition.
+	Seek(ctx context.Context, pos kafka_pkg.SeekPosition) (map[int]int64, error)
+}
+
+// control pauses fetching of App and tracks its state.
+type control struct {
+	mu           sync.Mutex
+	running      bool
+	paused       bool
+	resumed      chan struct{}
+	cancelFetch  context.CancelFunc
+	inFlight     int
+	lastOffsets  map[int]int64
+	lastCommitAt time.Time
+	lastErr      error
+	lastErrAt    time.Time
+	// seek is held while consumer group is moved, so consumer is not resumed in the middle.
+	seek sync.Mutex
+}
+
+func (c *control) setRunning(running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = running
+}
+
+// beginFetch waits while consumer is paused and returns context of fetch, which is canceled by Pause.
+// Fetched message is in flight until it is committed.
+func (c *control) beginFetch(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	for {
+		c.mu.Lock()
+		if !c.paused {
+			fetchCtx, cancel := context.WithCancel(ctx)
+			c.cancelFetch = cancel
+			c.inFlight++
+			c.mu.Unlock()
+			return fetchCtx, cancel, nil
+		}
+		resumed := c.resumed
+		c.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// fetchFailed releases message which is failed to be fetched.
+func (c *control) fetchFailed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+}
+
+func (c *control) committed(msgs []kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight -= len(msgs)
+	if c.lastOffsets == nil {
+		c.lastOffsets = make(map[int]int64)
+	}
+	// Messages of partition are committed in order, but batch may be not sorted
+	updated := make(map[int]bool)
+	for _, msg := range msgs {
+		if !updated[msg.Partition] || msg.Offset > c.lastOffsets[msg.Partition] {
+			c.lastOffsets[msg.Partition] = msg.Offset
+			updated[msg.Partition] = true
+		}
+	}
+	c.lastCommitAt = time.Now()
+}
+
+func (c *control) failed(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr = err
+	c.lastErrAt = time.Now()
+}
+
+// pause stops fetching. Fetch in progress is interrupted, fetched messages are processed and committed.
+func (c *control) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.resumed = make(chan struct{})
+	if c.cancelFetch != nil {
+		c.cancelFetch()
+	}
+}
+
+func (c *control) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	close(c.resumed)
+}
+
+func (c *control) idle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused && c.inFlight == 0
+}
+
+func (c *control) state(topic string) kafka_pkg.State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := kafka_pkg.State{
+		Topic:       topic,
+		Status:      kafka_pkg.StatusStopped,
+		InFlight:    c.inFlight,
+		LastOffsets: maps.Clone(c.lastOffsets),
+	}
+	switch {
+	case c.running && c.paused:
+		res.Status = kafka_pkg.StatusPaused
+	case c.running:
+		res.Status = kafka_pkg.StatusRunning
+	}
+	if !c.lastCommitAt.IsZero() {
+		res.LastCommitAt = &c.lastCommitAt
+	}
+	if c.lastErr != nil {
+		res.LastError = c.lastErr.Error()
+		res.LastErrorAt = &c.lastErrAt
+	}
+	return res
+}
+
+// fetchMessage fetches next message from consumer, waiting while app is paused.
+// Fetch interrupted by Pause returns errPaused.
+func (a *App) fetchMessage(ctx context.Context) (kafka.Message, error) {
+	fetchCtx, cancel, err := a.control.beginFetch(ctx)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	defer cancel()
+
+	msg, err := a.consumer.FetchMessage(fetchCtx)
+	if err != nil {
+		a.control.fetchFailed()
+		if ctx.Err() == nil && fetchCtx.Err() != nil {
+			return kafka.Message{}, errPaused
+		}
+		return kafka.Message{}, err
+	}
+	return msg, nil
+}
+
+// State returns status of consumer, its last committed offsets and the last error.
+func (a *App) State() kafka_pkg.State {
+	return a.control.state(a.topic)
+}
+
+// Pause stops fetching messages. It doesn't wait for messages in progress:
+// consumer is idle when they are committed and State reports no messages in flight.
+func (a *App) Pause() kafka_pkg.State {
+	a.control.pause()
+	logger.Info("Kafka consumer paused", "topic", a.topic)
+	return a.State()
+}
+
+// Resume continues fetching messages. It waits for seek in progress.
+func (a *App) Resume() kafka_pkg.State {
+	a.control.seek.Lock()
+	defer a.control.seek.Unlock()
+	a.control.resume()
+	logger.Info("Kafka consumer resumed", "topic", a.topic)
+	return a.State()
+}
+
+// Seek moves consumer group to position, so messages are consumed from there after Resume.
+// Consumer must be paused and idle, otherwise messages in progress would be committed after seek.
+func (a *App) Seek(ctx context.Context, pos kafka_pkg.SeekPosition) (map[int]int64, error) {
+	seeker, ok := a.consumer.(Seeker)
+	if !ok {
+		return nil, kafka_pkg.ErrSeekUnsupported
+	}
+	a.control.seek.Lock()
+	defer a.control.seek.Unlock()
+	if !a.control.idle() {
+		return nil, kafka_pkg.ErrNotIdle
+	}
+
+	offsets, err := seeker.Seek(ctx, pos)
+	if err != nil {
+		err = fmt.Errorf("seek topic %q: %w", a.topic, err)
+		a.control.failed(err)
+		return nil, err
+	}
+	logger.Info("Kafka consumer group moved", "topic", a.topic, "offsets", offsets)
+	return offsets, nil
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wb-L0-task/internal/domain/services/order"
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekableSource serves messages sent to msgs and reports committed ones.
+type seekableSource struct {
+	msgs      chan kafka.Message
+	committed chan kafka.Message
+	seeks     []kafka_pkg.SeekPosition
+}
+
+func newSeekableSource() *seekableSource {
+	return &seekableSource{msgs: make(chan kafka.Message), committed: make(chan kafka.Message, 16)}
+}
+
+func (s *seekableSource) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case msg := <-s.msgs:
+		return msg, nil
+	}
+}
+
+func (s *seekableSource) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		s.committed <- msg
+	}
+	return nil
+}
+
+func (s *seekableSource) Close() error { return nil }
+
+func (s *seekableSource) Seek(_ context.Context, pos kafka_pkg.SeekPosition) (map[int]int64, error) {
+	s.seeks = append(s.seeks, pos)
+	return map[int]int64{0: pos.Offset}, nil
+}
+
+func (s *seekableSource) awaitCommit(t *testing.T) kafka.Message {
+	t.Helper()
+	select {
+	case msg := <-s.committed:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message is not committed")
+		return kafka.Message{}
+	}
+}
+
+func TestApp_PauseSeekResume(t *testing.T) {
+	source := newSeekableSource()
+	app := New("orders", source, TopicHandlers{"orders": &recordingHandler{result: order.SaveCreated}}, Options{})
+	assert.Equal(t, kafka_pkg.StatusStopped, app.State().Status)
+	go app.Run(context.Background())
+	defer app.stopConsuming()
+
+	source.msgs <- kafka.Message{Topic: "orders", Offset: 5}
+	source.awaitCommit(t)
+	assert.Equal(t, kafka_pkg.StatusRunning, app.State().Status)
+
+	_, err := app.Seek(context.Background(), kafka_pkg.SeekPosition{Offset: 3})
+	require.ErrorIs(t, err, kafka_pkg.ErrNotIdle)
+
+	state := app.Pause()
+	assert.Equal(t, kafka_pkg.StatusPaused, state.Status)
+	assert.Eventually(t, func() bool { return app.State().InFlight == 0 }, time.Second, time.Millisecond)
+	select {
+	case source.msgs <- kafka.Message{Topic: "orders", Offset: 6}:
+		t.Fatal("message is fetched by paused consumer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	offsets, err := app.Seek(context.Background(), kafka_pkg.SeekPosition{Offset: 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 3}, offsets)
+	assert.Len(t, source.seeks, 1)
+
+	app.Resume()
+	source.msgs <- kafka.Message{Topic: "orders", Offset: 3}
+	assert.Equal(t, int64(3), source.awaitCommit(t).Offset)
+
+	state = app.State()
+	assert.Equal(t, kafka_pkg.StatusRunning, state.Status)
+	assert.Equal(t, map[int]int64{0: 3}, state.LastOffsets)
+	assert.NotNil(t, state.LastCommitAt)
+	assert.Empty(t, state.LastError)
+}
+
+func TestApp_PauseWorkers(t *testing.T) {
+	source := newSeekableSource()
+	app := New("orders", source, TopicHandlers{"orders": &recordingHandler{result: order.SaveCreated}},
+		Options{Workers: 2})
+	go app.Run(context.Background())
+	defer app.stopConsuming()
+
+	source.msgs <- kafka.Message{Topic: "orders", Partition: 1, Offset: 7}
+	app.Pause()
+	source.awaitCommit(t)
+
+	assert.Eventually(t, func() bool { return app.State().InFlight == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, map[int]int64{1: 7}, app.State().LastOffsets)
+}
+
+func TestApp_State_LastError(t *testing.T) {
+	source := newSeekableSource()
+	app := New("orders", source, TopicHandlers{}, Options{})
+	go app.Run(context.Background())
+	defer app.stopConsuming()
+
+	source.msgs <- kafka.Message{Topic: "payments", Offset: 1}
+	source.awaitCommit(t)
+
+	state := app.State()
+	assert.Contains(t, state.LastError, "topic")
+	assert.NotNil(t, state.LastErrorAt)
+}
+
+func TestApp_Seek_Unsupported(t *testing.T) {
+	app := New("orders", &idleSource{}, TopicHandlers{}, Options{})
+	app.Pause()
+
+	_, err := app.Seek(context.Background(), kafka_pkg.SeekPosition{})
+
+	require.ErrorIs(t, err, kafka_pkg.ErrSeekUnsupported)
+}
@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers added to messages forwarded to dead letter topic.
+const (
+	HeaderDLQReason          = "dlq-reason"
+	HeaderDLQErrorTemplate   = "dlq-error-template"
+	HeaderDLQErrorEntity     = "dlq-error-entity"
+	HeaderDLQSourceTopic     = "dlq-source-topic"
+	HeaderDLQSourcePartition = "dlq-source-partition"
+	HeaderDLQSourceOffset    = "dlq-source-offset"
+	HeaderDLQTimestamp       = "dlq-timestamp"
+)
+
+type DeadLetterWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// isRejected reports whether message can never be processed because of its content, so it is dead lettered
+// without being retried. Unknown order is transient for handlers which wait for its creation, see awaitOrder.
+func isRejected(err error) bool {
+	return errors.Is(err, serviceErrors.ErrBrokenEntity) ||
+		errors.Is(err, serviceErrors.ErrInvalidEntity) ||
+		errors.Is(err, serviceErrors.ErrConflict) ||
+		errors.Is(err, serviceErrors.ErrOutdated) ||
+		errors.Is(err, serviceErrors.ErrNotFound)
+}
+
+// deadLetterMessage builds message with original key, payload and headers,
+// extended by failure reason and position of the source message.
+func deadLetterMessage(msg kafka.Message, reason error, failedAt time.Time) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+7)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQReason, Value: []byte(reason.Error())},
+		kafka.Header{Key: HeaderDLQSourceTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQSourcePartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQSourceOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQTimestamp, Value: []byte(failedAt.UTC().Format(time.RFC3339Nano))},
+	)
+
+	var entityErr *serviceErrors.EntityError
+	if errors.As(reason, &entityErr) {
+		headers = append(headers,
+			kafka.Header{Key: HeaderDLQErrorTemplate, Value: []byte(entityErr.Template)},
+			kafka.Header{Key: HeaderDLQErrorEntity, Value: []byte(entityErr.Entity())},
+		)
+	}
+
+	return kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func headersMap(headers []kafka.Header) map[string]string {
+	res := make(map[string]string, len(headers))
+	for _, h := range headers {
+		res[h.Key] = string(h.Value)
+	}
+	return res
+}
+
+func TestDeadLetterMessage(t *testing.T) {
+	msg := kafka.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Key:       []byte("order-key"),
+		Value:     []byte(`{"order_uid":""}`),
+		Headers:   []kafka.Header{{Key: "trace-id", Value: []byte("abc")}},
+	}
+	failedAt := time.Date(2025, 8, 6, 9, 30, 58, 0, time.FixedZone("MSK", 3*60*60))
+
+	res := deadLetterMessage(msg, serviceErrors.ErrInvalidEntity.ForEntity("order_uid"), failedAt)
+
+	assert.Empty(t, res.Topic)
+	assert.Equal(t, msg.Key, res.Key)
+	assert.Equal(t, msg.Value, res.Value)
+	assert.Equal(t, map[string]string{
+		"trace-id":               "abc",
+		HeaderDLQReason:          "Failed to pass validation field: order_uid",
+		HeaderDLQErrorTemplate:   "Failed to pass validation field: {entity}",
+		HeaderDLQErrorEntity:     "order_uid",
+		HeaderDLQSourceTopic:     "orders",
+		HeaderDLQSourcePartition: "2",
+		HeaderDLQSourceOffset:    "42",
+		HeaderDLQTimestamp:       "2025-08-06T06:30:58Z",
+	}, headersMap(res.Headers))
+}
+
+func TestDeadLetterMessage_NotEntityError(t *testing.T) {
+	msg := kafka.Message{Topic: "orders", Value: []byte("{")}
+
+	res := deadLetterMessage(msg, errors.New("unexpected"), time.Now())
+
+	headers := headersMap(res.Headers)
+	assert.Equal(t, "unexpected", headers[HeaderDLQReason])
+	assert.NotContains(t, headers, HeaderDLQErrorTemplate)
+	assert.NotContains(t, headers, HeaderDLQErrorEntity)
+}
+
+func TestIsRejected(t *testing.T) {
+	assert.True(t, isRejected(serviceErrors.ErrBrokenEntity.ForEntity("order")))
+	assert.True(t, isRejected(serviceErrors.ErrInvalidEntity.ForEntity("order.delivery.phone")))
+	assert.True(t, isRejected(serviceErrors.ErrConflict.ForEntity("order")))
+	assert.True(t, isRejected(serviceErrors.ErrOutdated.ForEntity("order version")))
+	// Update of unknown order can never be applied
+	assert.True(t, isRejected(serviceErrors.ErrNotFound.ForEntity("order")))
+	assert.False(t, isRejected(serviceErrors.ErrUnavailable.ForEntity("storage")))
+	assert.False(t, isRejected(errors.New("connection refused")))
+}
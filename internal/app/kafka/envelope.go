@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Envelope wraps JSON payload of event with its metadata.
+// Message without envelope is a bare payload, described only by headers.
+type Envelope struct {
+	// EventType overrides event-type header, if set.
+	EventType string `json:"event_type"`
+	// SchemaVersion selects upgrade of order payload to the current order.
+	SchemaVersion int             `json:"schema_version"`
+	EventID       string          `json:"event_id"`
+	ProducedAt    time.Time       `json:"produced_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// unwrapEnvelope returns envelope of message and true, or false if message is not enveloped.
+// Only JSON message with schema_version field is enveloped, so bare orders keep working.
+func unwrapEnvelope(msg kafka.Message) (*Envelope, bool, error) {
+	if contentType := header(msg, HeaderContentType); contentType != "" &&
+		!strings.HasPrefix(strings.ToLower(contentType), order.ContentTypeJSON) {
+		return nil, false, nil
+	}
+
+	var probe struct {
+		SchemaVersion json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(msg.Value, &probe); err != nil || probe.SchemaVersion == nil {
+		return nil, false, nil
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		logger.Error("Failed to unmarshal envelope", "error", err)
+		return nil, true, serviceErrors.ErrBrokenEntity.ForEntity("envelope")
+	}
+	if envelope.SchemaVersion <= 0 {
+		return nil, true, serviceErrors.ErrInvalidEntity.ForEntity("schema_version")
+	}
+	return &envelope, true, nil
+}
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderContentType tells how order is encoded. Message without it is JSON.
+const HeaderContentType = "content-type"
+
+// HeaderEventType tells which event message carries.
+// Message without it creates order, unless it is a tombstone, which deletes order identified by key.
+const HeaderEventType = "event-type"
+
+// Event types of input messages.
+const (
+	EventOrderCreated = "order.created"
+	EventOrderUpdated = "order.updated"
+	EventOrderDeleted = "order.deleted"
+)
+
+// header returns value of the first header with key, or empty string if there is no such header.
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// eventType returns event type of message.
+func eventType(msg kafka.Message) string {
+	if eventType := header(msg, HeaderEventType); eventType != "" {
+		return eventType
+	}
+	if len(msg.Value) == 0 {
+		return EventOrderDeleted
+	}
+	return EventOrderCreated
+}
+
+// orderMessage returns order carried by message with its content type.
+func orderMessage(msg kafka.Message) order.Message {
+	return order.Message{
+		ContentType: header(msg, HeaderContentType),
+		Value:       msg.Value,
+		Topic:       msg.Topic,
+		Partition:   msg.Partition,
+	}
+}
+
+// event is input message unwrapped from envelope, if it has one.
+type event struct {
+	Type  string
+	Key   []byte
+	Order order.Message
+}
+
+// parseEvent returns event carried by message. Event type of envelope must agree with event-type header.
+func parseEvent(msg kafka.Message) (event, error) {
+	envelope, ok, err := unwrapEnvelope(msg)
+	if err != nil {
+		return event{}, err
+	}
+	if !ok {
+		return event{Type: eventType(msg), Key: msg.Key, Order: orderMessage(msg)}, nil
+	}
+
+	headerType := header(msg, HeaderEventType)
+	if envelope.EventType != "" && headerType != "" && envelope.EventType != headerType {
+		logger.Error("Event type of envelope differs from header",
+			"envelope", envelope.EventType, "header", headerType, "event_id", envelope.EventID)
+		return event{}, serviceErrors.ErrBrokenEntity.ForEntity("event type")
+	}
+	res := event{
+		Type: envelope.EventType,
+		Key:  msg.Key,
+		Order: order.Message{
+			ContentType:   order.ContentTypeJSON,
+			SchemaVersion: envelope.SchemaVersion,
+			Value:         envelope.Payload,
+			Topic:         msg.Topic,
+			Partition:     msg.Partition,
+		},
+	}
+	if res.Type == "" {
+		res.Type = eventType(msg)
+	}
+	logger.Debug("Received enveloped event", "event_type", res.Type, "event_id", envelope.EventID,
+		"schema_version", envelope.SchemaVersion, "produced_at", envelope.ProducedAt)
+	return res, nil
+}
+
+// OrderEvents handles topic of order events, passing every event to service by its type.
+type OrderEvents struct {
+	service *order.KafkaConsumerService
+}
+
+func NewOrderEvents(service *order.KafkaConsumerService) *OrderEvents {
+	return &OrderEvents{service: service}
+}
+
+// Handle passes message to service by its event type. Message of unknown event type is rejected.
+func (h *OrderEvents) Handle(ctx context.Context, msg kafka.Message) (order.SaveResult, error) {
+	event, err := parseEvent(msg)
+	if err != nil {
+		return order.SaveFailed, err
+	}
+
+	switch event.Type {
+	case EventOrderCreated:
+		return h.service.SaveOrder(ctx, event.Order)
+	case EventOrderUpdated:
+		return h.service.UpdateOrder(ctx, event.Order.Value)
+	case EventOrderDeleted:
+		return h.service.DeleteOrder(ctx, event.Key, event.Order.Value)
+	default:
+		return order.SaveFailed, serviceErrors.ErrBrokenEntity.ForEntity("event type")
+	}
+}
+
+// HandleBatch saves every run of consecutive created orders at once and handles other events one by one
+// between the runs, so events are applied in offset order.
+func (h *OrderEvents) HandleBatch(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+	outcomes := make([]order.SaveOutcome, 0, len(msgs))
+	var created []order.Message
+	saveCreated := func() error {
+		if len(created) == 0 {
+			return nil
+		}
+		saved, err := h.service.SaveOrders(ctx, created)
+		if err != nil {
+			return err
+		}
+		outcomes = append(outcomes, saved...)
+		created = nil
+		return nil
+	}
+
+	for i, msg := range msgs {
+		// Message which can't be parsed is passed to Handle, which reports the error
+		if event, err := parseEvent(msg); err == nil && event.Type == EventOrderCreated {
+			created = append(created, event.Order)
+			continue
+		}
+		if err := saveCreated(); err != nil {
+			return nil, err
+		}
+		handled, err := handleEach(ctx, h.Handle, msgs[i:i+1])
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, handled...)
+	}
+	if err := saveCreated(); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
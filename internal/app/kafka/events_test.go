@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/domain/services/order"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventType(t *testing.T) {
+	assert.Equal(t, EventOrderCreated, eventType(kafka.Message{Value: []byte(`{"order_uid":"order1"}`)}))
+	assert.Equal(t, EventOrderDeleted, eventType(kafka.Message{Key: []byte("order1")}))
+	assert.Equal(t, EventOrderUpdated, eventType(kafka.Message{Headers: []kafka.Header{
+		{Key: "trace-id", Value: []byte("abc")},
+		{Key: HeaderEventType, Value: []byte(EventOrderUpdated)},
+	}}))
+}
+
+func TestOrderEvents_Handle_UnknownEventType(t *testing.T) {
+	handler := NewOrderEvents(nil)
+	msg := kafka.Message{Headers: []kafka.Header{{Key: HeaderEventType, Value: []byte("order.archived")}}}
+
+	result, err := handler.Handle(context.Background(), msg)
+
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+	assert.Equal(t, order.SaveFailed, result)
+	assert.True(t, isRejected(err))
+}
+
+func TestOrderMessage(t *testing.T) {
+	msg := kafka.Message{
+		Value:   []byte{1, 2, 3},
+		Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(order.ContentTypeProtobuf)}},
+	}
+
+	assert.Equal(t, order.Message{ContentType: order.ContentTypeProtobuf, Value: []byte{1, 2, 3}}, orderMessage(msg))
+	assert.Empty(t, orderMessage(kafka.Message{Value: []byte(`{}`)}).ContentType)
+}
+
+func TestParseEvent(t *testing.T) {
+	bareOrder := []byte(`{"order_uid":"order1","version":2}`)
+	tests := []struct {
+		name     string
+		msg      kafka.Message
+		expected event
+	}{
+		{
+			name:     "bare order",
+			msg:      kafka.Message{Key: []byte("order1"), Value: bareOrder},
+			expected: event{Type: EventOrderCreated, Key: []byte("order1"), Order: order.Message{Value: bareOrder}},
+		},
+		{
+			name: "binary order is not unwrapped",
+			msg: kafka.Message{
+				Value:   []byte(`{"schema_version":1}`),
+				Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(order.ContentTypeProtobuf)}},
+			},
+			expected: event{Type: EventOrderCreated, Order: order.Message{
+				ContentType: order.ContentTypeProtobuf, Value: []byte(`{"schema_version":1}`),
+			}},
+		},
+		{
+			name: "enveloped order",
+			msg: kafka.Message{Value: []byte(`{"event_type":"order.created","schema_version":1,` +
+				`"event_id":"e1","produced_at":"2025-10-18T12:00:00Z","payload":{"order_uid":"order1"}}`)},
+			expected: event{Type: EventOrderCreated, Order: order.Message{
+				ContentType: order.ContentTypeJSON, SchemaVersion: 1, Value: []byte(`{"order_uid":"order1"}`),
+			}},
+		},
+		{
+			name: "event type from header",
+			msg: kafka.Message{
+				Key:     []byte("order1"),
+				Value:   []byte(`{"schema_version":2,"payload":{"order_uid":"order1","version":3}}`),
+				Headers: []kafka.Header{{Key: HeaderEventType, Value: []byte(EventOrderUpdated)}},
+			},
+			expected: event{Type: EventOrderUpdated, Key: []byte("order1"), Order: order.Message{
+				ContentType: order.ContentTypeJSON, SchemaVersion: 2, Value: []byte(`{"order_uid":"order1","version":3}`),
+			}},
+		},
+		{
+			name: "enveloped deletion without payload",
+			msg: kafka.Message{
+				Key:   []byte("order1"),
+				Value: []byte(`{"event_type":"order.deleted","schema_version":2}`),
+			},
+			expected: event{Type: EventOrderDeleted, Key: []byte("order1"), Order: order.Message{
+				ContentType: order.ContentTypeJSON, SchemaVersion: 2,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseEvent(tt.msg)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, event)
+		})
+	}
+}
+
+func TestParseEvent_BrokenEnvelope(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  kafka.Message
+	}{
+		{name: "malformed metadata", msg: kafka.Message{Value: []byte(`{"schema_version":1,"produced_at":"yesterday"}`)}},
+		{name: "zero schema version", msg: kafka.Message{Value: []byte(`{"schema_version":0,"payload":{}}`)}},
+		{
+			name: "event type differs from header",
+			msg: kafka.Message{
+				Value:   []byte(`{"event_type":"order.created","schema_version":2,"payload":{}}`),
+				Headers: []kafka.Header{{Key: HeaderEventType, Value: []byte(EventOrderDeleted)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewOrderEvents(nil)
+
+			result, err := handler.Handle(context.Background(), tt.msg)
+
+			require.Error(t, err)
+			assert.Equal(t, order.SaveFailed, result)
+			assert.True(t, isRejected(err))
+		})
+	}
+}
+
+func createdOrder(uid string) kafka.Message {
+	return kafka.Message{Key: []byte(uid), Value: []byte(`{"order_uid":"` + uid + `","version":1,` +
+		`"delivery":{"phone":"+79161234567","email":"test@example.com"},` +
+		`"payment":{"amount":1500,"payment_dt":1760788800,"delivery_cost":500,"goods_total":1000},` +
+		`"items":[{"price":1000,"total_price":1000}]}`)}
+}
+
+func TestOrderEvents_HandleBatch_KeepsOrder(t *testing.T) {
+	repo := new(order.MockRepository)
+	var calls []string
+	repo.On("SaveBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		for _, saved := range args.Get(1).([]*models.Order) {
+			calls = append(calls, "save "+saved.UID)
+		}
+	})
+	repo.On("Delete", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		calls = append(calls, "delete "+args.String(1))
+	})
+	handler := NewOrderEvents(order.NewKafkaConsumerService(repo, order.KafkaConsumerOptions{}))
+
+	outcomes, err := handler.HandleBatch(context.Background(), []kafka.Message{
+		createdOrder("order1"),
+		{Key: []byte("order1")},
+		createdOrder("order1"),
+		createdOrder("order2"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"save order1", "delete order1", "save order1", "save order2"}, calls)
+	assert.Equal(t, []order.SaveOutcome{
+		{Result: order.SaveCreated}, {Result: order.SaveDeleted}, {Result: order.SaveCreated}, {Result: order.SaveCreated},
+	}, outcomes)
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Handler processes messages of one topic.
+type Handler interface {
+	// Handle processes message. Error which is not transient rejects message.
+	Handle(ctx context.Context, msg kafka.Message) (order.SaveResult, error)
+}
+
+// BatchHandler is a Handler which processes batch of messages faster than one by one.
+type BatchHandler interface {
+	Handler
+	// HandleBatch returns outcome of every message.
+	// Error is returned only if all messages failed and may be retried together.
+	HandleBatch(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error)
+}
+
+// HandlerRegistry routes messages to handlers by topic.
+type HandlerRegistry interface {
+	Handler(topic string) (Handler, bool)
+}
+
+// TopicHandlers is a HandlerRegistry keyed by topic name.
+type TopicHandlers map[string]Handler
+
+func (h TopicHandlers) Handler(topic string) (Handler, bool) { //nolint:ireturn
+	handler, ok := h[topic]
+	return handler, ok
+}
+
+// handleEach handles messages one by one.
+// Transient failure of any message fails all of them, so they are retried together.
+func handleEach(
+	ctx context.Context,
+	handle func(ctx context.Context, msg kafka.Message) (order.SaveResult, error),
+	msgs []kafka.Message,
+) ([]order.SaveOutcome, error) {
+	outcomes := make([]order.SaveOutcome, len(msgs))
+	for i, msg := range msgs {
+		result, err := handle(ctx, msg)
+		if isTransient(err) {
+			return nil, err
+		}
+		outcomes[i] = order.SaveOutcome{Result: result, Err: err}
+	}
+	return outcomes, nil
+}
+
+// OrderUpdates handles topic of order updates. Message may be wrapped in envelope.
+type OrderUpdates struct {
+	service *order.KafkaConsumerService
+}
+
+func NewOrderUpdates(service *order.KafkaConsumerService) *OrderUpdates {
+	return &OrderUpdates{service: service}
+}
+
+func (h *OrderUpdates) Handle(ctx context.Context, msg kafka.Message) (order.SaveResult, error) {
+	event, err := parseEvent(msg)
+	if err != nil {
+		return order.SaveFailed, err
+	}
+	return awaitOrder(h.service.UpdateOrder(ctx, event.Order.Value))
+}
+
+// PaymentConfirmations handles topic of confirmed payments. Message may be wrapped in envelope.
+type PaymentConfirmations struct {
+	service *order.KafkaConsumerService
+}
+
+func NewPaymentConfirmations(service *order.KafkaConsumerService) *PaymentConfirmations {
+	return &PaymentConfirmations{service: service}
+}
+
+func (h *PaymentConfirmations) Handle(ctx context.Context, msg kafka.Message) (order.SaveResult, error) {
+	event, err := parseEvent(msg)
+	if err != nil {
+		return order.SaveFailed, err
+	}
+	return awaitOrder(h.service.ConfirmPayment(ctx, event.Order.Value))
+}
+
+// awaitOrder makes failure of unknown order transient. Updates and payment confirmations are consumed
+// independently of orders, so they may arrive before order is created. They are retried by retry policy
+// until order is created, and dead lettered after that, like other transient failures.
+func awaitOrder(result order.SaveResult, err error) (order.SaveResult, error) {
+	if errors.Is(err, serviceErrors.ErrNotFound) {
+		return result, fmt.Errorf("%w: %w", serviceErrors.ErrUnavailable.ForEntity("order"), err)
+	}
+	return result, err
+}
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler records handled messages and reports the same result for all of them.
+type recordingHandler struct {
+	result  order.SaveResult
+	handled []kafka.Message
+}
+
+func (h *recordingHandler) Handle(_ context.Context, msg kafka.Message) (order.SaveResult, error) {
+	h.handled = append(h.handled, msg)
+	return h.result, nil
+}
+
+// recordingBatchHandler records batches and reports SaveCreated for every message.
+type recordingBatchHandler struct {
+	recordingHandler
+	batches [][]kafka.Message
+}
+
+func (h *recordingBatchHandler) HandleBatch(_ context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+	h.batches = append(h.batches, msgs)
+	outcomes := make([]order.SaveOutcome, len(msgs))
+	for i := range outcomes {
+		outcomes[i] = order.SaveOutcome{Result: order.SaveCreated}
+	}
+	return outcomes, nil
+}
+
+func TestApp_Handle_RoutesByTopic(t *testing.T) {
+	orders := &recordingHandler{result: order.SaveCreated}
+	updates := &recordingHandler{result: order.SaveUpdated}
+	app := &App{handlers: TopicHandlers{"orders": orders, "order-updates": updates}}
+
+	result, err := app.handle(context.Background(), kafka.Message{Topic: "order-updates", Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, order.SaveUpdated, result)
+
+	result, err = app.handle(context.Background(), kafka.Message{Topic: "payments", Offset: 2})
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+	assert.Equal(t, order.SaveFailed, result)
+	assert.True(t, isRejected(err))
+
+	assert.Empty(t, orders.handled)
+	assert.Len(t, updates.handled, 1)
+}
+
+func TestApp_HandleBatch(t *testing.T) {
+	orders := &recordingBatchHandler{}
+	updates := &recordingHandler{result: order.SaveUpdated}
+	app := &App{handlers: TopicHandlers{"orders": orders, "order-updates": updates}}
+
+	// Batch of one topic is passed to its batch handler
+	batch := []kafka.Message{{Topic: "orders", Offset: 1}, {Topic: "orders", Offset: 2}}
+	outcomes, err := app.handleBatch(context.Background(), batch)
+	require.NoError(t, err)
+	assert.Equal(t, [][]kafka.Message{batch}, orders.batches)
+	assert.Len(t, outcomes, 2)
+
+	// Handler without batch support handles messages one by one
+	outcomes, err = app.handleBatch(context.Background(), []kafka.Message{
+		{Topic: "order-updates", Offset: 1}, {Topic: "order-updates", Offset: 2},
+	})
+	require.NoError(t, err)
+	assert.Len(t, updates.handled, 2)
+	assert.Equal(t, []order.SaveOutcome{{Result: order.SaveUpdated}, {Result: order.SaveUpdated}}, outcomes)
+
+	// Batch of different topics is handled one by one
+	outcomes, err = app.handleBatch(context.Background(), []kafka.Message{
+		{Topic: "orders", Offset: 3}, {Topic: "order-updates", Offset: 3},
+	})
+	require.NoError(t, err)
+	assert.Len(t, orders.batches, 1)
+	assert.Len(t, orders.handled, 1)
+	assert.Len(t, updates.handled, 3)
+	assert.Len(t, outcomes, 2)
+}
+
+func TestAwaitOrder(t *testing.T) {
+	notFound := serviceErrors.ErrNotFound.ForEntity("order")
+
+	result, err := awaitOrder(order.SaveFailed, notFound)
+
+	// Update of order which is not created yet is retried
+	assert.Equal(t, order.SaveFailed, result)
+	require.ErrorIs(t, err, notFound)
+	assert.True(t, isTransient(err))
+
+	invalid := serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
+	_, err = awaitOrder(order.SaveFailed, invalid)
+	assert.Equal(t, invalid, err)
+	assert.False(t, isTransient(err))
+}
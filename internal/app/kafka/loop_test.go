@@ -0,0 +1,234 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedHandler fails messages with errors scripted by offset, one error per attempt, and records offsets of
+// handled messages by partition. It is safe for concurrent use by workers.
+type scriptedHandler struct {
+	mu       sync.Mutex
+	failures map[int64][]error
+	attempts map[int64]int
+	handled  map[int][]int64
+}
+
+func newScriptedHandler(failures map[int64][]error) *scriptedHandler {
+	return &scriptedHandler{failures: failures, attempts: make(map[int64]int), handled: make(map[int][]int64)}
+}
+
+func (h *scriptedHandler) Handle(_ context.Context, msg kafka.Message) (order.SaveResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts[msg.Offset]++
+	if errs := h.failures[msg.Offset]; len(errs) > 0 {
+		h.failures[msg.Offset] = errs[1:]
+		return order.SaveFailed, errs[0]
+	}
+	h.handled[msg.Partition] = append(h.handled[msg.Partition], msg.Offset)
+	return order.SaveCreated, nil
+}
+
+type recordingDeadLetter struct {
+	mu   sync.Mutex
+	msgs []kafka.Message
+}
+
+func (d *recordingDeadLetter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.msgs = append(d.msgs, msgs...)
+	return nil
+}
+
+func (d *recordingDeadLetter) Close() error { return nil }
+
+// sendMessages sends messages of topic orders with offsets from 0 to n-1, spread over partitions, then ends source.
+func sendMessages(t *testing.T, source *MemorySource, n, partitions int) {
+	t.Helper()
+	for offset := range n {
+		require.NoError(t, source.Send(context.Background(), kafka.Message{
+			Topic:     "orders",
+			Partition: offset % partitions,
+			Offset:    int64(offset),
+		}))
+	}
+	source.End()
+}
+
+// runUntilEOF runs app until source reports io.EOF.
+func runUntilEOF(t *testing.T, app *App) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		app.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("app is still running after EOF")
+	}
+}
+
+func TestLoop_CommitsMessagesUntilEOF(t *testing.T) {
+	source := NewMemorySource(8)
+	handler := newScriptedHandler(nil)
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{})
+	sendMessages(t, source, 5, 2)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(5), offset)
+	offset, _ = source.Committed("orders", 1)
+	assert.Equal(t, int64(4), offset)
+	assert.Equal(t, map[int][]int64{0: {0, 2, 4}, 1: {1, 3}}, handler.handled)
+	assert.Equal(t, kafka_pkg.StatusStopped, app.State().Status)
+}
+
+func TestLoop_CommitsAfterPermanentFailure(t *testing.T) {
+	source := NewMemorySource(8)
+	deadLetter := &recordingDeadLetter{}
+	handler := newScriptedHandler(map[int64][]error{1: {serviceErrors.ErrBrokenEntity.ForEntity("order")}})
+	app := New("orders", source, TopicHandlers{"orders": handler},
+		Options{DeadLetter: deadLetter, Retry: testRetryPolicy(3)})
+	sendMessages(t, source, 3, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(3), offset)
+	assert.Equal(t, 1, handler.attempts[1], "permanent failure is not retried")
+	assert.Equal(t, []int64{0, 2}, handler.handled[0])
+	require.Len(t, deadLetter.msgs, 1)
+	assert.Equal(t, "1", headersMap(deadLetter.msgs[0].Headers)[HeaderDLQSourceOffset])
+}
+
+func TestLoop_UnclassifiedFailureIsDeadLettered(t *testing.T) {
+	source := NewMemorySource(8)
+	deadLetter := &recordingDeadLetter{}
+	handler := newScriptedHandler(map[int64][]error{0: {errors.New("unexpected")}})
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{DeadLetter: deadLetter})
+	sendMessages(t, source, 2, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(2), offset)
+	require.Len(t, deadLetter.msgs, 1)
+	assert.Equal(t, "0", headersMap(deadLetter.msgs[0].Headers)[HeaderDLQSourceOffset])
+}
+
+func TestLoop_CommitsAfterRetriesExhausted(t *testing.T) {
+	source := NewMemorySource(8)
+	deadLetter := &recordingDeadLetter{}
+	handler := newScriptedHandler(map[int64][]error{0: {errTransient, errTransient, errTransient}})
+	app := New("orders", source, TopicHandlers{"orders": handler},
+		Options{DeadLetter: deadLetter, Retry: testRetryPolicy(2)})
+	sendMessages(t, source, 2, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(2), offset)
+	assert.Equal(t, 3, handler.attempts[0])
+	assert.Equal(t, []int64{1}, handler.handled[0])
+	assert.Len(t, deadLetter.msgs, 1)
+}
+
+func TestLoop_RetriesAreNotLimitedWithoutDeadLetter(t *testing.T) {
+	source := NewMemorySource(8)
+	handler := newScriptedHandler(map[int64][]error{0: {errTransient, errTransient, errTransient, errTransient}})
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{Retry: testRetryPolicy(2)})
+	sendMessages(t, source, 1, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(1), offset)
+	assert.Equal(t, 5, handler.attempts[0])
+	assert.Equal(t, []int64{0}, handler.handled[0], "message is not dropped after retries are exhausted")
+}
+
+func TestLoop_TransientFailureIsRetried(t *testing.T) {
+	source := NewMemorySource(8)
+	deadLetter := &recordingDeadLetter{}
+	handler := newScriptedHandler(map[int64][]error{0: {errTransient, errTransient}})
+	app := New("orders", source, TopicHandlers{"orders": handler},
+		Options{DeadLetter: deadLetter, Retry: testRetryPolicy(0)})
+	sendMessages(t, source, 1, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(1), offset)
+	assert.Equal(t, 3, handler.attempts[0])
+	assert.Empty(t, deadLetter.msgs)
+}
+
+func TestLoop_NotCommittedOnShutdown(t *testing.T) {
+	source := NewMemorySource(8)
+	failures := make([]error, 1000)
+	for i := range failures {
+		failures[i] = errTransient
+	}
+	handler := newScriptedHandler(map[int64][]error{0: failures})
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{Retry: testRetryPolicy(0)})
+	require.NoError(t, source.Send(context.Background(), kafka.Message{Topic: "orders"}))
+	go app.Run(context.Background())
+	require.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return handler.attempts[0] > 1
+	}, time.Second, time.Millisecond)
+
+	app.Shutdown()
+
+	_, ok := source.Committed("orders", 0)
+	assert.False(t, ok, "message failing until shutdown is redelivered after restart")
+	require.ErrorIs(t, source.Send(context.Background(), kafka.Message{}), ErrSourceClosed)
+}
+
+func TestLoop_Batches(t *testing.T) {
+	source := NewMemorySource(8)
+	handler := &recordingBatchHandler{}
+	app := New("orders", source, TopicHandlers{"orders": handler},
+		Options{Batch: BatchPolicy{Size: 2, Timeout: time.Second}})
+	sendMessages(t, source, 5, 1)
+
+	runUntilEOF(t, app)
+
+	offset, _ := source.Committed("orders", 0)
+	assert.Equal(t, int64(5), offset)
+	require.Len(t, handler.batches, 3)
+	assert.Len(t, handler.batches[2], 1, "messages fetched before EOF are processed")
+}
+
+func TestLoop_Workers(t *testing.T) {
+	source := NewMemorySource(32)
+	handler := newScriptedHandler(map[int64][]error{4: {serviceErrors.ErrInvalidEntity.ForEntity("order")}})
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{Workers: 3})
+	sendMessages(t, source, 30, 3)
+
+	runUntilEOF(t, app)
+
+	for partition := range 3 {
+		offset, _ := source.Committed("orders", partition)
+		assert.Equal(t, int64(27+partition+1), offset)
+		assert.IsIncreasing(t, handler.handled[partition], "messages of partition are handled in order")
+	}
+	assert.Len(t, handler.handled[1], 9)
+}
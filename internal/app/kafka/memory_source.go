@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	kafka_pkg "wb-L0-task/internal/pkg/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message sources reading Kafka and memory.
+var (
+	_ MessageSource = (*kafka_pkg.GroupReader)(nil)
+	_ MessageSource = (*kafka_pkg.StoredOffsetReader)(nil)
+	_ MessageSource = (*MemorySource)(nil)
+	_ Seeker        = (*kafka_pkg.GroupReader)(nil)
+	_ Seeker        = (*kafka_pkg.StoredOffsetReader)(nil)
+	_ LagReader     = (*kafka_pkg.GroupReader)(nil)
+	_ LagReader     = (*kafka_pkg.StoredOffsetReader)(nil)
+)
+
+var ErrSourceClosed = errors.New("message source is closed")
+
+// MemorySource is a MessageSource backed by channel, so App runs without broker.
+// Like consumer group it keeps offsets of the next messages to consume by topic and partition.
+type MemorySource struct {
+	msgs      chan kafka.Message
+	closed    chan struct{}
+	closeOnce sync.Once
+	endOnce   sync.Once
+	mu        sync.Mutex
+	committed map[string]map[int]int64
+}
+
+// NewMemorySource creates source which buffers up to size messages.
+func NewMemorySource(size int) *MemorySource {
+	return &MemorySource{
+		msgs:      make(chan kafka.Message, size),
+		closed:    make(chan struct{}),
+		committed: make(map[string]map[int]int64),
+	}
+}
+
+// Send adds messages to source. It blocks while buffer is full.
+// Messages must not be sent after End.
+func (s *MemorySource) Send(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		if s.isClosed() {
+			return ErrSourceClosed
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.closed:
+			return ErrSourceClosed
+		case s.msgs <- msg:
+		}
+	}
+	return nil
+}
+
+// End tells that no more messages are sent. FetchMessage returns io.EOF after sent messages are fetched.
+func (s *MemorySource) End() {
+	s.endOnce.Do(func() { close(s.msgs) })
+}
+
+// FetchMessage returns next sent message. io.EOF is returned after End or Close.
+func (s *MemorySource) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if s.isClosed() {
+		return kafka.Message{}, io.EOF
+	}
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-s.closed:
+		return kafka.Message{}, io.EOF
+	case msg, ok := <-s.msgs:
+		if !ok {
+			return kafka.Message{}, io.EOF
+		}
+		return msg, nil
+	}
+}
+
+// CommitMessages stores offsets of the next messages to consume. Offsets never move back.
+func (s *MemorySource) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	if s.isClosed() {
+		return ErrSourceClosed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range msgs {
+		committed, ok := s.committed[msg.Topic]
+		if !ok {
+			committed = make(map[int]int64)
+			s.committed[msg.Topic] = committed
+		}
+		committed[msg.Partition] = max(committed[msg.Partition], msg.Offset+1)
+	}
+	return nil
+}
+
+// Committed returns offset of the next message to consume from partition of topic.
+// False is returned if nothing is committed.
+func (s *MemorySource) Committed(topic string, partition int) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.committed[topic][partition]
+	return offset, ok
+}
+
+// Close stops fetching, messages which are not fetched are dropped.
+func (s *MemorySource) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+func (s *MemorySource) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySource(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemorySource(2)
+	require.NoError(t, source.Send(ctx,
+		kafka.Message{Topic: "orders", Partition: 0, Offset: 7},
+		kafka.Message{Topic: "orders", Partition: 1, Offset: 3},
+	))
+	source.End()
+
+	first, err := source.FetchMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), first.Offset)
+	second, err := source.FetchMessage(ctx)
+	require.NoError(t, err)
+	_, err = source.FetchMessage(ctx)
+	require.ErrorIs(t, err, io.EOF)
+
+	_, ok := source.Committed("orders", 0)
+	assert.False(t, ok)
+	require.NoError(t, source.CommitMessages(ctx, first, second))
+	require.NoError(t, source.CommitMessages(ctx, kafka.Message{Topic: "orders", Partition: 0, Offset: 2}))
+	offset, ok := source.Committed("orders", 0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), offset, "committed offset never moves back")
+	offset, _ = source.Committed("orders", 1)
+	assert.Equal(t, int64(4), offset)
+}
+
+func TestMemorySource_Close(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemorySource(1)
+	require.NoError(t, source.Send(ctx, kafka.Message{Topic: "orders"}))
+
+	require.NoError(t, source.Close())
+	require.NoError(t, source.Close())
+
+	_, err := source.FetchMessage(ctx)
+	require.ErrorIs(t, err, io.EOF)
+	require.ErrorIs(t, source.Send(ctx, kafka.Message{Topic: "orders"}), ErrSourceClosed)
+	require.ErrorIs(t, source.CommitMessages(ctx, kafka.Message{Topic: "orders"}), ErrSourceClosed)
+}
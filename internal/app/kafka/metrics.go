@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"wb-L0-task/internal/domain/services/order"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Metrics observes consumed messages by topic and partition.
+type Metrics interface {
+	// ObserveMessage reports outcome of message processed and committed in elapsed time.
+	ObserveMessage(topic string, partition int, outcome string, elapsed time.Duration)
+	// SetLag reports number of messages of partition after the last committed one.
+	SetLag(topic string, partition int, lag int64)
+}
+
+// defaultLagInterval is a period of lag reports if it is not set by options.
+const defaultLagInterval = 15 * time.Second
+
+// LagReader is implemented by message source which can tell lag of its consumer group.
+type LagReader interface {
+	// Lag returns number of messages after the last committed one by partition.
+	Lag(ctx context.Context) (map[int]int64, error)
+}
+
+// observe reports outcomes of committed messages, which are processed since start.
+func (a *App) observe(msgs []kafka.Message, outcomes []order.SaveOutcome, start time.Time) {
+	if a.metrics == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	for i, msg := range msgs {
+		a.metrics.ObserveMessage(msg.Topic, msg.Partition, order.Outcome(outcomes[i].Result, outcomes[i].Err), elapsed)
+	}
+}
+
+// reportLag reports lag of consumer group periodically until ctx is done.
+// Lag is read from source, so it grows while consumer is paused or stalled.
+func (a *App) reportLag(ctx context.Context, source LagReader) {
+	ticker := time.NewTicker(a.lagInterval)
+	defer ticker.Stop()
+	for {
+		lag, err := source.Lag(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to read consumer lag", "topic", a.topic, "err", err)
+		}
+		for partition, messages := range lag {
+			a.metrics.SetLag(a.topic, partition, messages)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/domain/services/order"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMetrics struct {
+	mu       sync.Mutex
+	outcomes map[int][]string
+	lags     map[int]int64
+}
+
+func (m *recordingMetrics) ObserveMessage(_ string, partition int, outcome string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes[partition] = append(m.outcomes[partition], outcome)
+}
+
+func (m *recordingMetrics) SetLag(_ string, partition int, lag int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lags[partition] = lag
+}
+
+func (m *recordingMetrics) observed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res int
+	for _, outcomes := range m.outcomes {
+		res += len(outcomes)
+	}
+	return res
+}
+
+func TestApp_Observe(t *testing.T) {
+	metrics := &recordingMetrics{outcomes: make(map[int][]string), lags: make(map[int]int64)}
+	app := New("orders", nil, TopicHandlers{}, Options{Metrics: metrics})
+	msgs := []kafka.Message{
+		{Topic: "orders", Partition: 0, Offset: 5, HighWaterMark: 10},
+		{Topic: "orders", Partition: 1, Offset: 9, HighWaterMark: 10},
+		{Topic: "orders", Partition: 2, Offset: 3},
+	}
+	outcomes := []order.SaveOutcome{
+		{Result: order.SaveDuplicate},
+		{Result: order.SaveFailed, Err: serviceErrors.ErrBrokenEntity},
+		{Result: order.SaveCreated},
+	}
+
+	app.observe(msgs, outcomes, time.Now())
+
+	assert.Equal(t, map[int][]string{
+		0: {order.OutcomeDuplicate}, 1: {order.OutcomeBroken}, 2: {order.OutcomeSaved},
+	}, metrics.outcomes)
+	assert.Empty(t, metrics.lags, "lag is not reported on commit")
+}
+
+func TestApp_ObservesCommittedMessages(t *testing.T) {
+	source := newSeekableSource()
+	metrics := &recordingMetrics{outcomes: make(map[int][]string), lags: make(map[int]int64)}
+	app := New("orders", source, TopicHandlers{"orders": &recordingHandler{result: order.SaveDuplicate}},
+		Options{Metrics: metrics})
+	go app.Run(context.Background())
+	defer app.stopConsuming()
+
+	source.msgs <- kafka.Message{Topic: "orders", Partition: 0, Offset: 5, HighWaterMark: 10}
+	source.msgs <- kafka.Message{Topic: "payments", Partition: 1, Offset: 9, HighWaterMark: 10}
+	source.awaitCommit(t)
+	source.awaitCommit(t)
+
+	assert.Eventually(t, func() bool { return metrics.observed() == 2 }, time.Second, time.Millisecond)
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, map[int][]string{0: {order.OutcomeDuplicate}, 1: {order.OutcomeBroken}}, metrics.outcomes)
+}
+
+// lagSource is a memory source which lag grows on every read.
+type lagSource struct {
+	*MemorySource
+	reads atomic.Int64
+}
+
+func (s *lagSource) Lag(context.Context) (map[int]int64, error) {
+	return map[int]int64{0: s.reads.Add(1)}, nil
+}
+
+func (m *recordingMetrics) lag(partition int) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lags[partition]
+}
+
+func TestApp_ReportsLagPeriodically(t *testing.T) {
+	source := &lagSource{MemorySource: NewMemorySource(1)}
+	metrics := &recordingMetrics{outcomes: make(map[int][]string), lags: make(map[int]int64)}
+	app := New("orders", source, TopicHandlers{}, Options{Metrics: metrics, LagInterval: time.Millisecond})
+	go app.Run(context.Background())
+
+	// Lag is reported while no message is consumed
+	assert.Eventually(t, func() bool { return metrics.lag(0) >= 3 }, time.Second, time.Millisecond)
+	app.stopConsuming()
+	reads := source.reads.Load()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, reads, source.reads.Load(), "lag is not read after stop")
+	assert.Equal(t, 0, metrics.observed())
+}
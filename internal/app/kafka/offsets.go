@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+
+	"wb-L0-task/internal/domain/services/order"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// OffsetStore keeps offsets of processed messages in database.
+type OffsetStore interface {
+	// Advance stores offset of the next message to consume.
+	// It returns false if stored offset is not less, so messages before offset are already processed.
+	Advance(ctx context.Context, topic string, partition int, offset int64) (bool, error)
+}
+
+// TrManager runs fn in transaction. Storage calls made with ctx passed to fn join it.
+type TrManager interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// OffsetStorage makes processing exactly-once: offset of message is stored in the same transaction
+// as its processing result, and message which offset is already stored is skipped.
+type OffsetStorage struct {
+	TrManager TrManager
+	Store     OffsetStore
+}
+
+// processFunc processes messages and returns outcome of every message.
+// Error is returned only if all messages failed and may be retried together.
+type processFunc func(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error)
+
+// transaction runs fn in one transaction with storing offsets of msgs, if offsets are stored in database.
+// Messages which are already processed are not passed to fn and reported with SaveDuplicate result.
+// Offsets of failed messages are stored too, they are dead lettered after transaction is committed.
+// Caches are updated only after transaction is committed.
+func (a *App) transaction(ctx context.Context, msgs []kafka.Message, fn processFunc) ([]order.SaveOutcome, error) {
+	if a.offsets == nil {
+		return fn(ctx, msgs)
+	}
+
+	var outcomes []order.SaveOutcome
+	var cacheUpdates order.CacheUpdates
+	err := a.offsets.TrManager.Do(order.WithCacheUpdates(ctx, &cacheUpdates), func(ctx context.Context) error {
+		outcomes = make([]order.SaveOutcome, len(msgs))
+		fresh := make([]kafka.Message, 0, len(msgs))
+		indexes := make([]int, 0, len(msgs))
+		for i, msg := range msgs {
+			advanced, err := a.offsets.Store.Advance(ctx, msg.Topic, msg.Partition, msg.Offset+1)
+			if err != nil {
+				return err
+			}
+			if !advanced {
+				logger.Info("Message is already processed, skip it",
+					"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+				outcomes[i] = order.SaveOutcome{Result: order.SaveDuplicate}
+				continue
+			}
+			fresh = append(fresh, msg)
+			indexes = append(indexes, i)
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		processed, err := fn(ctx, fresh)
+		if err != nil {
+			return err
+		}
+		for j, i := range indexes {
+			outcomes[i] = processed[j]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cacheUpdates.Apply(ctx)
+	return outcomes, nil
+}
@@ -0,0 +1,296 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/domain/services/order"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTrManager runs fn without transaction and remembers whether it failed.
+type fakeTrManager struct {
+	calls      int
+	rolledBack bool
+}
+
+func (m *fakeTrManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	err := fn(ctx)
+	m.rolledBack = err != nil
+	return err
+}
+
+// fakeOffsetStore keeps offsets in memory.
+type fakeOffsetStore struct {
+	offsets map[int]int64
+	err     error
+}
+
+func (s *fakeOffsetStore) Advance(_ context.Context, _ string, partition int, offset int64) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.offsets[partition] >= offset {
+		return false, nil
+	}
+	s.offsets[partition] = offset
+	return true, nil
+}
+
+func succeed(result order.SaveResult, seen *[]kafka.Message) processFunc {
+	return func(_ context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+		*seen = append(*seen, msgs...)
+		outcomes := make([]order.SaveOutcome, len(msgs))
+		for i := range outcomes {
+			outcomes[i] = order.SaveOutcome{Result: result}
+		}
+		return outcomes, nil
+	}
+}
+
+func TestTransaction_WithoutOffsetStorage(t *testing.T) {
+	app := &App{}
+	msgs := []kafka.Message{{Offset: 1}, {Offset: 2}}
+	var seen []kafka.Message
+
+	outcomes, err := app.transaction(context.Background(), msgs, succeed(order.SaveCreated, &seen))
+
+	require.NoError(t, err)
+	assert.Equal(t, msgs, seen)
+	assert.Len(t, outcomes, 2)
+}
+
+func TestTransaction_SkipsProcessedMessages(t *testing.T) {
+	trManager := &fakeTrManager{}
+	store := &fakeOffsetStore{offsets: map[int]int64{0: 6}}
+	app := &App{offsets: &OffsetStorage{TrManager: trManager, Store: store}}
+	msgs := []kafka.Message{
+		{Partition: 0, Offset: 5},
+		{Partition: 1, Offset: 5},
+		{Partition: 0, Offset: 6},
+	}
+	var seen []kafka.Message
+
+	outcomes, err := app.transaction(context.Background(), msgs, succeed(order.SaveCreated, &seen))
+
+	require.NoError(t, err)
+	assert.Equal(t, []kafka.Message{msgs[1], msgs[2]}, seen)
+	assert.Equal(t, []order.SaveOutcome{
+		{Result: order.SaveDuplicate},
+		{Result: order.SaveCreated},
+		{Result: order.SaveCreated},
+	}, outcomes)
+	assert.Equal(t, map[int]int64{0: 7, 1: 6}, store.offsets)
+	assert.Equal(t, 1, trManager.calls)
+	assert.False(t, trManager.rolledBack)
+}
+
+func TestTransaction_FailedMessagesKeepOffsets(t *testing.T) {
+	trManager := &fakeTrManager{}
+	store := &fakeOffsetStore{offsets: map[int]int64{}}
+	app := &App{offsets: &OffsetStorage{TrManager: trManager, Store: store}}
+	rejected := serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
+
+	outcomes, err := app.transaction(context.Background(), []kafka.Message{{Offset: 3}},
+		func(_ context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+			return []order.SaveOutcome{{Result: order.SaveFailed, Err: rejected}}, nil
+		})
+
+	// Rejected message is processed, so it is not read again after restart
+	require.NoError(t, err)
+	assert.ErrorIs(t, outcomes[0].Err, serviceErrors.ErrInvalidEntity)
+	assert.Equal(t, int64(4), store.offsets[0])
+	assert.False(t, trManager.rolledBack)
+}
+
+func TestTransaction_TransientFailureRollsBack(t *testing.T) {
+	trManager := &fakeTrManager{}
+	store := &fakeOffsetStore{offsets: map[int]int64{}}
+	app := &App{offsets: &OffsetStorage{TrManager: trManager, Store: store}}
+	unavailable := serviceErrors.ErrUnavailable.ForEntity("storage")
+
+	outcomes, err := app.transaction(context.Background(), []kafka.Message{{Offset: 3}},
+		func(context.Context, []kafka.Message) ([]order.SaveOutcome, error) {
+			return nil, unavailable
+		})
+
+	require.ErrorIs(t, err, serviceErrors.ErrUnavailable)
+	assert.Nil(t, outcomes)
+	assert.True(t, trManager.rolledBack)
+}
+
+func TestTransaction_StoreError(t *testing.T) {
+	storeErr := errors.New("connection refused")
+	app := &App{offsets: &OffsetStorage{TrManager: &fakeTrManager{}, Store: &fakeOffsetStore{err: storeErr}}}
+	called := false
+
+	_, err := app.transaction(context.Background(), []kafka.Message{{Offset: 3}},
+		func(context.Context, []kafka.Message) ([]order.SaveOutcome, error) {
+			called = true
+			return nil, nil
+		})
+
+	require.ErrorIs(t, err, storeErr)
+	assert.False(t, called)
+}
+
+func TestTransaction_UpdatesCacheAfterCommit(t *testing.T) {
+	repo := new(order.MockRepository)
+	repo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	cache := new(order.MockCache[models.Order])
+	service := order.NewKafkaConsumerService(repo, order.KafkaConsumerOptions{Cache: cache})
+	store := &fakeOffsetStore{offsets: make(map[int]int64)}
+	app := &App{offsets: &OffsetStorage{TrManager: &fakeTrManager{}, Store: store}}
+	save := func(err error) processFunc {
+		return func(ctx context.Context, msgs []kafka.Message) ([]order.SaveOutcome, error) {
+			result, saveErr := service.SaveOrder(ctx, orderMessage(msgs[0]))
+			require.NoError(t, saveErr)
+			cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+			return []order.SaveOutcome{{Result: result}}, err
+		}
+	}
+	first, second := createdOrder("order1"), createdOrder("order2")
+	second.Offset = 1
+
+	_, err := app.transaction(context.Background(), []kafka.Message{first}, save(errTransient))
+	require.ErrorIs(t, err, errTransient)
+	cache.On("Set", "order2", mock.Anything, time.Duration(0)).Once()
+	_, err = app.transaction(context.Background(), []kafka.Message{second}, save(nil))
+	require.NoError(t, err)
+
+	cache.AssertExpectations(t)
+}
+
+// journal records events of message processing in order of their occurrence.
+type journal struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (j *journal) record(event string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, event)
+}
+
+// journalingTrManager records commit of every successful transaction and rolls back offsets of failed one.
+// First failCommits transactions fail on commit with transient error.
+type journalingTrManager struct {
+	journal     *journal
+	store       *fakeOffsetStore
+	failCommits int
+}
+
+func (m *journalingTrManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	offsets := maps.Clone(m.store.offsets)
+	if err := fn(ctx); err != nil {
+		m.store.offsets = offsets
+		return err
+	}
+	if m.failCommits > 0 {
+		m.failCommits--
+		m.store.offsets = offsets
+		return errTransient
+	}
+	m.journal.record("commit")
+	return nil
+}
+
+// journalingDeadLetter records every dead lettered message.
+type journalingDeadLetter struct {
+	journal *journal
+}
+
+func (d *journalingDeadLetter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		d.journal.record("dead letter " + headersMap(msg.Headers)[HeaderDLQSourceOffset])
+	}
+	return nil
+}
+
+func (d *journalingDeadLetter) Close() error { return nil }
+
+func TestLoop_DeadLettersOnceAfterOffsetsAreStored(t *testing.T) {
+	j := &journal{}
+	store := &fakeOffsetStore{offsets: make(map[int]int64)}
+	source := NewMemorySource(8)
+	broken := serviceErrors.ErrBrokenEntity.ForEntity("order")
+	handler := newScriptedHandler(map[int64][]error{
+		0: {broken, broken},
+		1: {errTransient, errTransient},
+	})
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{
+		DeadLetter: &journalingDeadLetter{journal: j},
+		Retry:      testRetryPolicy(1),
+		Offsets: &OffsetStorage{
+			TrManager: &journalingTrManager{journal: j, store: store, failCommits: 1},
+			Store:     store,
+		},
+	})
+	sendMessages(t, source, 2, 1)
+
+	runUntilEOF(t, app)
+
+	// Transaction of message 0 is retried after failed commit, but message is dead lettered only once
+	assert.Equal(t, []string{"commit", "dead letter 0", "commit", "dead letter 1"}, j.events)
+	assert.Equal(t, int64(2), store.offsets[0], "offset of message failing after all retries is stored")
+}
+
+// failingDeadLetter counts writes which always fail.
+type failingDeadLetter struct {
+	mu     sync.Mutex
+	writes int
+}
+
+func (d *failingDeadLetter) WriteMessages(context.Context, ...kafka.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.writes++
+	return errors.New("leader not available")
+}
+
+func (d *failingDeadLetter) attempts() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writes
+}
+
+func (d *failingDeadLetter) Close() error { return nil }
+
+func TestLoop_NotDeadLetteredMessageIsNotCommitted(t *testing.T) {
+	store := &fakeOffsetStore{offsets: make(map[int]int64)}
+	source := NewMemorySource(8)
+	handler := newScriptedHandler(map[int64][]error{0: {serviceErrors.ErrBrokenEntity.ForEntity("order")}})
+	deadLetter := &failingDeadLetter{}
+	app := New("orders", source, TopicHandlers{"orders": handler}, Options{
+		DeadLetter: deadLetter,
+		Retry:      testRetryPolicy(1),
+		Offsets:    &OffsetStorage{TrManager: &fakeTrManager{}, Store: store},
+	})
+	sendMessages(t, source, 1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return deadLetter.attempts() > 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	_, committed := source.Committed("orders", 0)
+	assert.False(t, committed, "message is redelivered after restart")
+	assert.NotEmpty(t, app.State().LastError)
+}
@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/pkg/backoff"
+	"wb-L0-task/internal/pkg/logger"
+)
+
+// RetryPolicy describes how transient failures of message processing are retried.
+type RetryPolicy struct {
+	Backoff backoff.Backoff
+	// MaxRetries limits number of retries. 0 means retry until ctx is done.
+	MaxRetries int
+}
+
+// isTransient reports whether processing failed temporarily and may succeed if retried.
+func isTransient(err error) bool {
+	return errors.Is(err, serviceErrors.ErrUnavailable)
+}
+
+// retry calls fn until it succeeds, fails permanently, retries are exhausted or ctx is done.
+// Error of the last call is returned, or ctx error if it is done while waiting.
+func retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !isTransient(err) {
+			return err
+		}
+		if policy.MaxRetries > 0 && attempt >= policy.MaxRetries {
+			return err
+		}
+
+		delay := policy.Backoff.Delay(attempt)
+		logger.Warn("Transient failure, retry later", "attempt", attempt+1, "delay", delay, "err", err)
+		if waitErr := backoff.Wait(ctx, delay); waitErr != nil {
+			return waitErr
+		}
+	}
+}
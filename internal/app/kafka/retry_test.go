@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	"wb-L0-task/internal/pkg/backoff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = fmt.Errorf("%w: %w", serviceErrors.ErrUnavailable.ForEntity("storage"), errors.New("connection reset"))
+
+func testRetryPolicy(maxRetries int) RetryPolicy {
+	return RetryPolicy{
+		Backoff:    backoff.Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, Multiplier: 2},
+		MaxRetries: maxRetries,
+	}
+}
+
+func TestRetry_TransientThenSuccess(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), testRetryPolicy(0), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetry_PermanentNotRetried(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), testRetryPolicy(0), func(context.Context) error {
+		calls++
+		return serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
+	})
+
+	require.ErrorIs(t, err, serviceErrors.ErrInvalidEntity)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetry_MaxRetriesExhausted(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), testRetryPolicy(2), func(context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	require.ErrorIs(t, err, serviceErrors.ErrUnavailable)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetry_StopsOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{Backoff: backoff.Backoff{Initial: time.Hour, Multiplier: 1}}
+
+	calls := 0
+	err := retry(ctx, policy, func(context.Context) error {
+		calls++
+		cancel()
+		return errTransient
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, isTransient(errTransient))
+	assert.False(t, isTransient(serviceErrors.ErrBrokenEntity.ForEntity("order")))
+	assert.False(t, isTransient(errors.New("duplicate key")))
+}
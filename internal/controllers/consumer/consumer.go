@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"wb-L0-task/internal/pkg/kafka"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	errNoPosition        = errors.New("either offset or timestamp is required")
+	errNegativePartition = errors.New("partition must not be negative")
+	errNegativeOffset    = errors.New("offset must not be negative")
+)
+
+type Service interface {
+	States() []kafka.State
+	State(topic string) (kafka.State, error)
+	Pause(topic string) (kafka.State, error)
+	Resume(topic string) (kafka.State, error)
+	Seek(ctx context.Context, topic string, pos kafka.SeekPosition) (map[int]int64, error)
+}
+
+// Controller serves admin API of Kafka consumers.
+type Controller struct {
+	service Service
+}
+
+func New(service Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+// SeekRequest moves consumer group of topic to offset or to the first message produced at or after timestamp.
+// Without partition all partitions of topic are moved.
+type SeekRequest struct {
+	Partition *int       `json:"partition"`
+	Offset    *int64     `json:"offset"`
+	Timestamp *time.Time `json:"timestamp"`
+}
+
+type SeekResponse struct {
+	Topic string `json:"topic"`
+	// Offsets are offsets of the next messages to consume by partition.
+	Offsets map[int]int64 `json:"offsets"`
+}
+
+func (c *Controller) States() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, c.service.States())
+	}
+}
+
+func (c *Controller) State() http.HandlerFunc {
+	return c.stateHandler(c.service.State)
+}
+
+func (c *Controller) Pause() http.HandlerFunc {
+	return c.stateHandler(c.service.Pause)
+}
+
+func (c *Controller) Resume() http.HandlerFunc {
+	return c.stateHandler(c.service.Resume)
+}
+
+func (c *Controller) Seek() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topic := chi.URLParam(r, "topic")
+		var req SeekRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		pos, err := req.position()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		offsets, err := c.service.Seek(r.Context(), topic, pos)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, SeekResponse{Topic: topic, Offsets: offsets})
+	}
+}
+
+func (c *Controller) stateHandler(fn func(topic string) (kafka.State, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := fn(chi.URLParam(r, "topic"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, state)
+	}
+}
+
+func (r SeekRequest) position() (kafka.SeekPosition, error) {
+	if (r.Offset == nil) == (r.Timestamp == nil) {
+		return kafka.SeekPosition{}, errNoPosition
+	}
+	if r.Partition != nil && *r.Partition < 0 {
+		return kafka.SeekPosition{}, errNegativePartition
+	}
+	pos := kafka.SeekPosition{Partition: r.Partition}
+	if r.Timestamp != nil {
+		pos.Time = *r.Timestamp
+		return pos, nil
+	}
+	if *r.Offset < 0 {
+		return kafka.SeekPosition{}, errNegativeOffset
+	}
+	pos.Offset = *r.Offset
+	return pos, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, kafka.ErrUnknownTopic):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, kafka.ErrNotIdle), errors.Is(err, kafka.ErrPartitionNotAssigned):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, kafka.ErrUnknownPartition):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, kafka.ErrSeekUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode response", "err", err)
+	}
+}
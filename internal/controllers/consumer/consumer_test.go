@@ -0,0 +1,155 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wb-L0-task/internal/pkg/kafka"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStates(t *testing.T) {
+	mockService := NewMockService(t)
+	mockService.On("States").Return([]kafka.State{
+		{Topic: "orders", Status: kafka.StatusRunning, LastOffsets: map[int]int64{0: 41}},
+	}).Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).States().ServeHTTP(rr, createTestRequest(t, http.MethodGet, "", ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"topic":"orders","status":"running","in_flight":0,"last_offsets":{"0":41}}]`, rr.Body.String())
+}
+
+func TestState_UnknownTopic(t *testing.T) {
+	mockService := NewMockService(t)
+	mockService.On("State", "unknown").Return(kafka.State{}, kafka.ErrUnknownTopic).Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).State().ServeHTTP(rr, createTestRequest(t, http.MethodGet, "unknown", ""))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPause(t *testing.T) {
+	mockService := NewMockService(t)
+	mockService.On("Pause", "orders").
+		Return(kafka.State{Topic: "orders", Status: kafka.StatusPaused, InFlight: 2}, nil).
+		Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).Pause().ServeHTTP(rr, createTestRequest(t, http.MethodPost, "orders", ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var state kafka.State
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	assert.Equal(t, kafka.StatusPaused, state.Status)
+	assert.Equal(t, 2, state.InFlight)
+}
+
+func TestResume(t *testing.T) {
+	mockService := NewMockService(t)
+	mockService.On("Resume", "orders").Return(kafka.State{Topic: "orders", Status: kafka.StatusRunning}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).Resume().ServeHTTP(rr, createTestRequest(t, http.MethodPost, "orders", ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSeek_Offset(t *testing.T) {
+	mockService := NewMockService(t)
+	partition := 1
+	mockService.On("Seek", mock.Anything, "orders", kafka.SeekPosition{Partition: &partition, Offset: 100}).
+		Return(map[int]int64{1: 100}, nil).
+		Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).Seek().ServeHTTP(rr, createTestRequest(t, http.MethodPost, "orders", `{"partition":1,"offset":100}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"topic":"orders","offsets":{"1":100}}`, rr.Body.String())
+}
+
+func TestSeek_Timestamp(t *testing.T) {
+	mockService := NewMockService(t)
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockService.On("Seek", mock.Anything, "orders", kafka.SeekPosition{Time: ts}).
+		Return(map[int]int64{0: 10, 1: 12}, nil).
+		Once()
+
+	rr := httptest.NewRecorder()
+	New(mockService).Seek().ServeHTTP(rr,
+		createTestRequest(t, http.MethodPost, "orders", `{"timestamp":"2024-05-01T12:00:00Z"}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"topic":"orders","offsets":{"0":10,"1":12}}`, rr.Body.String())
+}
+
+func TestSeek_InvalidRequest(t *testing.T) {
+	tests := map[string]string{
+		"malformed":          `{"offset":`,
+		"no position":        `{"partition":0}`,
+		"offset and time":    `{"offset":1,"timestamp":"2024-05-01T12:00:00Z"}`,
+		"negative offset":    `{"offset":-1}`,
+		"negative partition": `{"partition":-1,"offset":1}`,
+		"invalid timestamp":  `{"timestamp":"yesterday"}`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockService := NewMockService(t)
+
+			rr := httptest.NewRecorder()
+			New(mockService).Seek().ServeHTTP(rr, createTestRequest(t, http.MethodPost, "orders", body))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			mockService.AssertNumberOfCalls(t, "Seek", 0)
+		})
+	}
+}
+
+func TestSeek_Errors(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		code int
+	}{
+		"not idle":          {err: kafka.ErrNotIdle, code: http.StatusConflict},
+		"not assigned":      {err: kafka.ErrPartitionNotAssigned, code: http.StatusConflict},
+		"unknown partition": {err: kafka.ErrUnknownPartition, code: http.StatusBadRequest},
+		"unsupported":       {err: kafka.ErrSeekUnsupported, code: http.StatusNotImplemented},
+		"unknown topic":     {err: kafka.ErrUnknownTopic, code: http.StatusNotFound},
+		"broker":            {err: assert.AnError, code: http.StatusInternalServerError},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockService := NewMockService(t)
+			mockService.On("Seek", mock.Anything, "orders", mock.Anything).Return(nil, tt.err).Once()
+
+			rr := httptest.NewRecorder()
+			New(mockService).Seek().ServeHTTP(rr, createTestRequest(t, http.MethodPost, "orders", `{"offset":0}`))
+
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
+
+func createTestRequest(t *testing.T, method, topic, body string) *http.Request {
+	req, err := http.NewRequest(method, "/admin/consumers/"+topic, strings.NewReader(body))
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	if topic != "" {
+		rctx.URLParams.Add("topic", topic)
+	}
+
+	return req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+}
@@ -39,7 +39,7 @@ func (_m *MockService) EXPECT() *MockService_Expecter {
 }
 
 // GetOrderById provides a mock function for the type MockService
-func (_mock *MockService) GetOrderById(ctx context.Context, orderId string) (*order.Order, error) {
+func (_mock *MockService) GetOrderById(ctx context.Context, orderId string) (*order.Order, bool, error) {
 	ret := _mock.Called(ctx, orderId)
 
 	if len(ret) == 0 {
@@ -47,8 +47,9 @@ func (_mock *MockService) GetOrderById(ctx context.Context, orderId string) (*or
 	}
 
 	var r0 *order.Order
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*order.Order, error)); ok {
+	var r1 bool
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*order.Order, bool, error)); ok {
 		return returnFunc(ctx, orderId)
 	}
 	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *order.Order); ok {
@@ -58,12 +59,17 @@ func (_mock *MockService) GetOrderById(ctx context.Context, orderId string) (*or
 			r0 = ret.Get(0).(*order.Order)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) bool); ok {
 		r1 = returnFunc(ctx, orderId)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(bool)
 	}
-	return r0, r1
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = returnFunc(ctx, orderId)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
 // MockService_GetOrderById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderById'
@@ -96,12 +102,12 @@ func (_c *MockService_GetOrderById_Call) Run(run func(ctx context.Context, order
 	return _c
 }
 
-func (_c *MockService_GetOrderById_Call) Return(order1 *order.Order, err error) *MockService_GetOrderById_Call {
-	_c.Call.Return(order1, err)
+func (_c *MockService_GetOrderById_Call) Return(order1 *order.Order, stale bool, err error) *MockService_GetOrderById_Call {
+	_c.Call.Return(order1, stale, err)
 	return _c
 }
 
-func (_c *MockService_GetOrderById_Call) RunAndReturn(run func(ctx context.Context, orderId string) (*order.Order, error)) *MockService_GetOrderById_Call {
+func (_c *MockService_GetOrderById_Call) RunAndReturn(run func(ctx context.Context, orderId string) (*order.Order, bool, error)) *MockService_GetOrderById_Call {
 	_c.Call.Return(run)
 	return _c
 }
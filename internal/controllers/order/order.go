@@ -13,8 +13,15 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	cacheHeader = "X-Cache"
+	cacheStale  = "STALE"
+	// staleWarning is a Warning header value for stale response, see RFC 7234
+	staleWarning = `110 - "Response is Stale"`
+)
+
 type Service interface {
-	GetOrderById(ctx context.Context, orderId string) (*model.Order, error)
+	GetOrderById(ctx context.Context, orderId string) (order *model.Order, stale bool, err error)
 }
 
 type Controller struct {
@@ -35,15 +42,20 @@ func (c *Controller) GetOrderById() http.HandlerFunc {
 			return
 		}
 
-		order, err := c.service.GetOrderById(r.Context(), orderUID)
+		order, stale, err := c.service.GetOrderById(r.Context(), orderUID)
 		if err != nil {
 			if errors.Is(err, serviceErrors.ErrNotFound) {
 				http.Error(w, err.Error(), http.StatusNotFound)
 				return
 			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
+		if stale {
+			w.Header().Set(cacheHeader, cacheStale)
+			w.Header().Set("Warning", staleWarning)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		if err = json.NewEncoder(w).Encode(order); err != nil {
 			logger.Error("Failed to encode response", "err", err)
@@ -28,7 +28,7 @@ func TestGetOrderById_Success(t *testing.T) {
 	}
 
 	mockService.On("GetOrderById", mock.Anything, "test123").
-		Return(expectedOrder, nil).
+		Return(expectedOrder, false, nil).
 		Once()
 
 	controller := New(mockService)
@@ -40,6 +40,7 @@ func TestGetOrderById_Success(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("X-Cache"))
 
 	var responseOrder model.Order
 	err := json.Unmarshal(rr.Body.Bytes(), &responseOrder)
@@ -49,11 +50,58 @@ func TestGetOrderById_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestGetOrderById_Stale(t *testing.T) {
+	mockService := NewMockService(t)
+
+	expectedOrder := &model.Order{UID: "test123"}
+
+	mockService.On("GetOrderById", mock.Anything, "test123").
+		Return(expectedOrder, true, nil).
+		Once()
+
+	controller := New(mockService)
+	handler := controller.GetOrderById()
+
+	req := createTestRequest(t, "test123")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "STALE", rr.Header().Get("X-Cache"))
+	assert.Equal(t, `110 - "Response is Stale"`, rr.Header().Get("Warning"))
+
+	var responseOrder model.Order
+	err := json.Unmarshal(rr.Body.Bytes(), &responseOrder)
+	require.NoError(t, err)
+	assert.Equal(t, "test123", responseOrder.UID)
+}
+
+func TestGetOrderById_InternalError(t *testing.T) {
+	mockService := NewMockService(t)
+
+	mockService.On("GetOrderById", mock.Anything, "test123").
+		Return(nil, false, assert.AnError).
+		Once()
+
+	controller := New(mockService)
+	handler := controller.GetOrderById()
+
+	req := createTestRequest(t, "test123")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, assert.AnError.Error()+"\n", rr.Body.String())
+	assert.Empty(t, rr.Header().Get("X-Cache"))
+}
+
 func TestGetOrderById_NotFound(t *testing.T) {
 	mockService := NewMockService(t)
 
 	mockService.On("GetOrderById", mock.Anything, "nonexistent").
-		Return(nil, serviceErrors.ErrNotFound.ForEntity("order")).
+		Return(nil, false, serviceErrors.ErrNotFound.ForEntity("order")).
 		Once()
 
 	controller := New(mockService)
@@ -0,0 +1,248 @@
+package decoders
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"wb-L0-task/api"
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/logger"
+	"wb-L0-task/internal/pkg/schemaregistry"
+
+	"github.com/hamba/avro/v2"
+)
+
+// Confluent wire format: magic byte, big-endian schema id and Avro binary payload.
+const (
+	wireMagicByte  = 0
+	wireHeaderSize = 5
+)
+
+var errNegativeAmount = errors.New("negative amount")
+
+// SchemaRegistry returns writer schemas of Avro messages by id.
+type SchemaRegistry interface {
+	// Schema returns schema registered with id, unknown id is reported with schemaregistry.ErrSchemaNotFound.
+	Schema(ctx context.Context, id int) (string, error)
+}
+
+// Avro decodes order serialized in Confluent wire format. Payload is read with writer schema
+// from schema registry and resolved to api.OrderAvroSchema, so producers may use any compatible schema.
+type Avro struct {
+	registry SchemaRegistry
+	reader   avro.Schema
+	mu       sync.RWMutex
+	resolved map[int]avro.Schema
+}
+
+func NewAvro(registry SchemaRegistry) (*Avro, error) {
+	reader, err := avro.Parse(api.OrderAvroSchema)
+	if err != nil {
+		return nil, fmt.Errorf("parse order avro schema: %w", err)
+	}
+	return &Avro{
+		registry: registry,
+		reader:   reader,
+		resolved: make(map[int]avro.Schema),
+	}, nil
+}
+
+func (d *Avro) Decode(ctx context.Context, value []byte) (*models.Order, error) {
+	if len(value) < wireHeaderSize || value[0] != wireMagicByte {
+		logger.Error("Avro order is not in Confluent wire format")
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	schemaID := int(binary.BigEndian.Uint32(value[1:wireHeaderSize]))
+
+	schema, err := d.schema(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// avro.Unmarshal ignores end of data, so truncated payload is detected with reader error
+	var msg avroOrder
+	reader := avro.NewReader(nil, 0).Reset(value[wireHeaderSize:])
+	if reader.ReadVal(schema, &msg); reader.Error != nil {
+		logger.Error("Failed to unmarshal avro order", "schema_id", schemaID, "error", reader.Error)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	order, err := msg.toOrder()
+	if err != nil {
+		logger.Error("Invalid avro order", "schema_id", schemaID, "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	return order, nil
+}
+
+// schema returns writer schema resolved to reader schema. Resolved schemas are cached by id.
+// Registry failures other than unknown id are temporary, so message may be retried.
+func (d *Avro) schema(ctx context.Context, id int) (avro.Schema, error) {
+	d.mu.RLock()
+	schema, ok := d.resolved[id]
+	d.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	raw, err := d.registry.Schema(ctx, id)
+	if errors.Is(err, schemaregistry.ErrSchemaNotFound) {
+		logger.Error("Unknown schema of avro order", "schema_id", id)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order schema")
+	}
+	if err != nil {
+		logger.Error("Failed to get schema of avro order", "schema_id", id, "error", err)
+		return nil, fmt.Errorf("%w: %w", serviceErrors.ErrUnavailable.ForEntity("schema registry"), err)
+	}
+
+	// Writer schemas have the same names as reader one, so they must not share parse cache
+	writer, err := avro.ParseWithCache(raw, "", &avro.SchemaCache{})
+	if err != nil {
+		logger.Error("Failed to parse schema of avro order", "schema_id", id, "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order schema")
+	}
+	schema, err = avro.NewSchemaCompatibility().Resolve(d.reader, writer)
+	if err != nil {
+		logger.Error("Schema of avro order is incompatible", "schema_id", id, "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order schema")
+	}
+
+	d.mu.Lock()
+	d.resolved[id] = schema
+	d.mu.Unlock()
+	return schema, nil
+}
+
+type avroOrder struct {
+	UID               string       `avro:"order_uid"`
+	TrackNumber       string       `avro:"track_number"`
+	Entry             string       `avro:"entry"`
+	Delivery          avroDelivery `avro:"delivery"`
+	Payment           avroPayment  `avro:"payment"`
+	Items             []avroItem   `avro:"items"`
+	Locale            string       `avro:"locale"`
+	InternalSignature string       `avro:"internal_signature"`
+	CustomerID        string       `avro:"customer_id"`
+	DeliveryService   string       `avro:"delivery_service"`
+	ShardKey          string       `avro:"shardkey"`
+	StockManagementID int64        `avro:"sm_id"`
+	DateCreated       time.Time    `avro:"date_created"`
+	OutOfFailureShard string       `avro:"oof_shard"`
+	Version           int64        `avro:"version"`
+}
+
+type avroDelivery struct {
+	Name    string `avro:"name"`
+	Phone   string `avro:"phone"`
+	Zip     string `avro:"zip"`
+	City    string `avro:"city"`
+	Address string `avro:"address"`
+	Region  string `avro:"region"`
+	Email   string `avro:"email"`
+}
+
+type avroPayment struct {
+	TransactionID string    `avro:"transaction"`
+	RequestID     string    `avro:"request_id"`
+	Currency      string    `avro:"currency"`
+	Provider      string    `avro:"provider"`
+	Amount        int64     `avro:"amount"`
+	PaymentDT     time.Time `avro:"payment_dt"`
+	Bank          string    `avro:"bank"`
+	DeliveryCost  int64     `avro:"delivery_cost"`
+	GoodsTotal    int64     `avro:"goods_total"`
+	CustomFee     int64     `avro:"custom_fee"`
+}
+
+type avroItem struct {
+	ChartID        int64  `avro:"chrt_id"`
+	TrackNumber    string `avro:"track_number"`
+	Price          int64  `avro:"price"`
+	RID            string `avro:"rid"`
+	Name           string `avro:"name"`
+	Sale           int64  `avro:"sale"`
+	Size           string `avro:"size"`
+	TotalPrice     int64  `avro:"total_price"`
+	NomenclatureID int64  `avro:"nm_id"`
+	Brand          string `avro:"brand"`
+	Status         int    `avro:"status"`
+}
+
+// toOrder converts message to order. Avro has no unsigned types, so negative amounts are rejected.
+func (m *avroOrder) toOrder() (*models.Order, error) {
+	var amounts amountConverter
+	order := &models.Order{
+		UID:         m.UID,
+		Version:     m.Version,
+		TrackNumber: m.TrackNumber,
+		Entry:       m.Entry,
+		Delivery: models.Delivery{
+			Name:    m.Delivery.Name,
+			Phone:   m.Delivery.Phone,
+			Zip:     m.Delivery.Zip,
+			City:    m.Delivery.City,
+			Address: m.Delivery.Address,
+			Region:  m.Delivery.Region,
+			Email:   m.Delivery.Email,
+		},
+		Locale:            m.Locale,
+		InternalSignature: m.InternalSignature,
+		CustomerID:        m.CustomerID,
+		DeliveryService:   m.DeliveryService,
+		ShardKey:          m.ShardKey,
+		StockManagementId: int(m.StockManagementID),
+		DateCreated:       m.DateCreated,
+		OutOfFailureShard: m.OutOfFailureShard,
+		Payment: models.Payment{
+			TransactionID: m.Payment.TransactionID,
+			RequestID:     m.Payment.RequestID,
+			Currency:      m.Payment.Currency,
+			Provider:      m.Payment.Provider,
+			Amount:        amounts.uint(m.Payment.Amount),
+			PaymentDT:     m.Payment.PaymentDT,
+			Bank:          m.Payment.Bank,
+			DeliveryCost:  amounts.uint(m.Payment.DeliveryCost),
+			GoodsTotal:    amounts.uint(m.Payment.GoodsTotal),
+			CustomFee:     amounts.uint(m.Payment.CustomFee),
+		},
+		Items: make([]models.Item, len(m.Items)),
+	}
+	for i, item := range m.Items {
+		order.Items[i] = models.Item{
+			ChartID:        item.ChartID,
+			TrackNumber:    item.TrackNumber,
+			Price:          amounts.uint(item.Price),
+			RID:            item.RID,
+			Name:           item.Name,
+			Sale:           amounts.uint(item.Sale),
+			Size:           item.Size,
+			TotalPrice:     amounts.uint(item.TotalPrice),
+			NomenclatureID: item.NomenclatureID,
+			Brand:          item.Brand,
+			Status:         item.Status,
+		}
+	}
+	if amounts.err != nil {
+		return nil, amounts.err
+	}
+	return order, nil
+}
+
+// amountConverter converts amounts to uint and remembers the first negative one.
+type amountConverter struct {
+	err error
+}
+
+func (c *amountConverter) uint(amount int64) uint {
+	if amount < 0 {
+		if c.err == nil {
+			c.err = fmt.Errorf("%w: %d", errNegativeAmount, amount)
+		}
+		return 0
+	}
+	return uint(amount) //nolint:gosec // amount is not negative
+}
@@ -0,0 +1,263 @@
+package decoders
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"wb-L0-task/api"
+	"wb-L0-task/api/orderpb"
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/schemaregistry"
+
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	dateCreated = time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	paymentDT   = time.Unix(1637907727, 0).UTC()
+)
+
+func expectedOrder() *models.Order {
+	return &models.Order{
+		UID:         "b563feb7b2b84b6test",
+		Version:     2,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: models.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: models.Payment{
+			TransactionID: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDT: paymentDT, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []models.Item{{
+			ChartID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, RID: "ab4219087a764ae0btest",
+			Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NomenclatureID: 2389212,
+			Brand: "Vivienne Sabo", Status: 202,
+		}},
+		Locale:            "en",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		ShardKey:          "9",
+		StockManagementId: 99,
+		DateCreated:       dateCreated,
+		OutOfFailureShard: "1",
+	}
+}
+
+func TestProtobuf_Decode(t *testing.T) {
+	value, err := proto.Marshal(&orderpb.Order{
+		OrderUid:    "b563feb7b2b84b6test",
+		Version:     2,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: &orderpb.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: &orderpb.Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDt: timestamppb.New(paymentDT), Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []*orderpb.Item{{
+			ChrtId: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest",
+			Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmId: 2389212,
+			Brand: "Vivienne Sabo", Status: 202,
+		}},
+		Locale:          "en",
+		CustomerId:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmId:            99,
+		DateCreated:     timestamppb.New(dateCreated),
+		OofShard:        "1",
+	})
+	require.NoError(t, err)
+
+	order, err := Protobuf{}.Decode(context.Background(), value)
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedOrder(), order)
+}
+
+func TestProtobuf_Decode_Broken(t *testing.T) {
+	_, err := Protobuf{}.Decode(context.Background(), []byte{0xff, 0xff})
+
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+}
+
+// fakeRegistry is a local stand-in of schema registry.
+type fakeRegistry struct {
+	schemas  map[int]string
+	err      error
+	requests int
+}
+
+func (r *fakeRegistry) Schema(_ context.Context, id int) (string, error) {
+	r.requests++
+	if r.err != nil {
+		return "", r.err
+	}
+	schema, ok := r.schemas[id]
+	if !ok {
+		return "", schemaregistry.ErrSchemaNotFound
+	}
+	return schema, nil
+}
+
+// writerSchemaV1 is order schema before version field was added.
+const writerSchemaV1 = `{
+  "type": "record", "name": "Order", "namespace": "order",
+  "fields": [
+    {"name": "order_uid", "type": "string"},
+    {"name": "track_number", "type": "string"},
+    {"name": "entry", "type": "string"},
+    {"name": "delivery", "type": {"type": "record", "name": "Delivery", "fields": [
+      {"name": "name", "type": "string"}, {"name": "phone", "type": "string"},
+      {"name": "zip", "type": "string"}, {"name": "city", "type": "string"},
+      {"name": "address", "type": "string"}, {"name": "region", "type": "string"},
+      {"name": "email", "type": "string"}]}},
+    {"name": "payment", "type": {"type": "record", "name": "Payment", "fields": [
+      {"name": "transaction", "type": "string"}, {"name": "request_id", "type": "string"},
+      {"name": "currency", "type": "string"}, {"name": "provider", "type": "string"},
+      {"name": "amount", "type": "long"},
+      {"name": "payment_dt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+      {"name": "bank", "type": "string"}, {"name": "delivery_cost", "type": "long"},
+      {"name": "goods_total", "type": "long"}, {"name": "custom_fee", "type": "long"}]}},
+    {"name": "items", "type": {"type": "array", "items": {"type": "record", "name": "Item", "fields": [
+      {"name": "chrt_id", "type": "long"}, {"name": "track_number", "type": "string"},
+      {"name": "price", "type": "long"}, {"name": "rid", "type": "string"},
+      {"name": "name", "type": "string"}, {"name": "sale", "type": "long"},
+      {"name": "size", "type": "string"}, {"name": "total_price", "type": "long"},
+      {"name": "nm_id", "type": "long"}, {"name": "brand", "type": "string"},
+      {"name": "status", "type": "int"}]}}},
+    {"name": "locale", "type": "string"},
+    {"name": "internal_signature", "type": "string"},
+    {"name": "customer_id", "type": "string"},
+    {"name": "delivery_service", "type": "string"},
+    {"name": "shardkey", "type": "string"},
+    {"name": "sm_id", "type": "long"},
+    {"name": "date_created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "oof_shard", "type": "string"}
+  ]
+}`
+
+func avroMessage(t *testing.T, schemaID int, schema string, msg avroOrder) []byte {
+	t.Helper()
+	parsed, err := avro.ParseWithCache(schema, "", &avro.SchemaCache{})
+	require.NoError(t, err)
+	payload, err := avro.Marshal(parsed, msg)
+	require.NoError(t, err)
+
+	value := make([]byte, wireHeaderSize, wireHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(value[1:], uint32(schemaID)) //nolint:gosec
+	return append(value, payload...)
+}
+
+func testAvroOrder() avroOrder {
+	return avroOrder{
+		UID:         "b563feb7b2b84b6test",
+		Version:     2,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: avroDelivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: avroPayment{
+			TransactionID: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDT: paymentDT, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []avroItem{{
+			ChartID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, RID: "ab4219087a764ae0btest",
+			Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NomenclatureID: 2389212,
+			Brand: "Vivienne Sabo", Status: 202,
+		}},
+		Locale:            "en",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		ShardKey:          "9",
+		StockManagementID: 99,
+		DateCreated:       dateCreated,
+		OutOfFailureShard: "1",
+	}
+}
+
+func TestAvro_Decode(t *testing.T) {
+	registry := &fakeRegistry{schemas: map[int]string{7: api.OrderAvroSchema}}
+	decoder, err := NewAvro(registry)
+	require.NoError(t, err)
+	value := avroMessage(t, 7, api.OrderAvroSchema, testAvroOrder())
+
+	order, err := decoder.Decode(context.Background(), value)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOrder(), order)
+
+	// Resolved schema is cached
+	_, err = decoder.Decode(context.Background(), value)
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.requests)
+}
+
+func TestAvro_Decode_OlderWriterSchema(t *testing.T) {
+	decoder, err := NewAvro(&fakeRegistry{schemas: map[int]string{3: writerSchemaV1}})
+	require.NoError(t, err)
+
+	order, err := decoder.Decode(context.Background(), avroMessage(t, 3, writerSchemaV1, testAvroOrder()))
+
+	require.NoError(t, err)
+	expected := expectedOrder()
+	expected.Version = 0
+	assert.Equal(t, expected, order)
+}
+
+func TestAvro_Decode_Rejected(t *testing.T) {
+	negative := testAvroOrder()
+	negative.Payment.Amount = -1
+
+	tests := []struct {
+		name  string
+		value []byte
+	}{
+		{name: "too short", value: []byte{0, 0, 0}},
+		{name: "wrong magic byte", value: append([]byte{1}, avroMessage(t, 7, api.OrderAvroSchema, testAvroOrder())[1:]...)},
+		{name: "unknown schema", value: avroMessage(t, 8, api.OrderAvroSchema, testAvroOrder())},
+		{name: "broken payload", value: []byte{0, 0, 0, 0, 7, 0xff}},
+		{name: "negative amount", value: avroMessage(t, 7, api.OrderAvroSchema, negative)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := NewAvro(&fakeRegistry{schemas: map[int]string{7: api.OrderAvroSchema}})
+			require.NoError(t, err)
+
+			_, err = decoder.Decode(context.Background(), tt.value)
+
+			require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+		})
+	}
+}
+
+func TestAvro_Decode_RegistryUnavailable(t *testing.T) {
+	registry := &fakeRegistry{err: errors.New("connection refused")}
+	decoder, err := NewAvro(registry)
+	require.NoError(t, err)
+	value := avroMessage(t, 7, api.OrderAvroSchema, testAvroOrder())
+
+	_, err = decoder.Decode(context.Background(), value)
+	require.ErrorIs(t, err, serviceErrors.ErrUnavailable)
+
+	// Failed lookup is not cached
+	registry.err = nil
+	registry.schemas = map[int]string{7: api.OrderAvroSchema}
+	_, err = decoder.Decode(context.Background(), value)
+	require.NoError(t, err)
+}
@@ -0,0 +1,98 @@
+// Package decoders implements decoders of binary order messages registered in order service by content type.
+package decoders
+
+import (
+	"context"
+	"time"
+
+	"wb-L0-task/api/orderpb"
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/logger"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Protobuf decodes order serialized as orderpb.Order.
+type Protobuf struct{}
+
+func (Protobuf) Decode(_ context.Context, value []byte) (*models.Order, error) {
+	var msg orderpb.Order
+	if err := proto.Unmarshal(value, &msg); err != nil {
+		logger.Error("Failed to unmarshal protobuf order", "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	return fromProto(&msg), nil
+}
+
+//nolint:gosec // Amounts are stored as uint, which is 64-bit on supported platforms
+func fromProto(msg *orderpb.Order) *models.Order {
+	order := &models.Order{
+		UID:               msg.GetOrderUid(),
+		Version:           msg.GetVersion(),
+		TrackNumber:       msg.GetTrackNumber(),
+		Entry:             msg.GetEntry(),
+		Locale:            msg.GetLocale(),
+		InternalSignature: msg.GetInternalSignature(),
+		CustomerID:        msg.GetCustomerId(),
+		DeliveryService:   msg.GetDeliveryService(),
+		ShardKey:          msg.GetShardkey(),
+		StockManagementId: int(msg.GetSmId()),
+		DateCreated:       protoTime(msg.GetDateCreated()),
+		OutOfFailureShard: msg.GetOofShard(),
+	}
+
+	if delivery := msg.GetDelivery(); delivery != nil {
+		order.Delivery = models.Delivery{
+			Name:    delivery.GetName(),
+			Phone:   delivery.GetPhone(),
+			Zip:     delivery.GetZip(),
+			City:    delivery.GetCity(),
+			Address: delivery.GetAddress(),
+			Region:  delivery.GetRegion(),
+			Email:   delivery.GetEmail(),
+		}
+	}
+
+	if payment := msg.GetPayment(); payment != nil {
+		order.Payment = models.Payment{
+			TransactionID: payment.GetTransaction(),
+			RequestID:     payment.GetRequestId(),
+			Currency:      payment.GetCurrency(),
+			Provider:      payment.GetProvider(),
+			Amount:        uint(payment.GetAmount()),
+			PaymentDT:     protoTime(payment.GetPaymentDt()),
+			Bank:          payment.GetBank(),
+			DeliveryCost:  uint(payment.GetDeliveryCost()),
+			GoodsTotal:    uint(payment.GetGoodsTotal()),
+			CustomFee:     uint(payment.GetCustomFee()),
+		}
+	}
+
+	order.Items = make([]models.Item, len(msg.GetItems()))
+	for i, item := range msg.GetItems() {
+		order.Items[i] = models.Item{
+			ChartID:        item.GetChrtId(),
+			TrackNumber:    item.GetTrackNumber(),
+			Price:          uint(item.GetPrice()),
+			RID:            item.GetRid(),
+			Name:           item.GetName(),
+			Sale:           uint(item.GetSale()),
+			Size:           item.GetSize(),
+			TotalPrice:     uint(item.GetTotalPrice()),
+			NomenclatureID: item.GetNmId(),
+			Brand:          item.GetBrand(),
+			Status:         int(item.GetStatus()),
+		}
+	}
+	return order
+}
+
+// protoTime converts timestamp to time, unset timestamp is zero time as in JSON orders without date.
+func protoTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -9,6 +9,13 @@ var (
 	ErrNotFound      = NewEntityError(404, "{entity} not found")
 	ErrInvalidEntity = NewEntityError(400, "Failed to pass validation field: {entity}")
 	ErrBrokenEntity  = NewEntityError(400, "Invalid entity received: {entity}")
+	ErrConflict      = NewEntityError(409, "Conflicting {entity} already exists")
+	// ErrOutdated means received change is older than stored state, e.g. update of lower version.
+	ErrOutdated = NewEntityError(412, "Outdated {entity} received")
+	// ErrNotModified means received change is already applied.
+	ErrNotModified = NewEntityError(304, "{entity} is not modified")
+	// ErrUnavailable means operation failed temporarily and may succeed if retried.
+	ErrUnavailable = NewEntityError(503, "{entity} is temporarily unavailable")
 )
 
 type EntityError struct {
@@ -29,6 +36,11 @@ func (e *EntityError) ForEntity(entity string) *EntityError {
 	}
 }
 
+// Entity returns name of entity the error is related to.
+func (e *EntityError) Entity() string {
+	return e.entity
+}
+
 func (e *EntityError) Error() string {
 	msg := e.Template
 	if e.entity != "" {
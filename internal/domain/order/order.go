@@ -29,6 +29,7 @@ func (o *Order) UnmarshalJSON(data []byte) error {
 
 type Order struct {
 	UID               string    `json:"order_uid"          db:"uid"`
+	Version           int64     `json:"version"            db:"version"`
 	TrackNumber       string    `json:"track_number"       db:"track_number"`
 	Entry             string    `json:"entry"              db:"entry"`
 	Delivery          Delivery  `json:"delivery"`
@@ -0,0 +1,20 @@
+package order
+
+// InitialVersion is a version of order which is not updated yet.
+const InitialVersion = 1
+
+// Update is a change of already stored order.
+// Only present parts are changed. Update is applied only if its version is greater than stored one.
+type Update struct {
+	UID      string       `json:"order_uid"`
+	Version  int64        `json:"version"`
+	Items    []ItemStatus `json:"items,omitempty"`
+	Delivery *Delivery    `json:"delivery,omitempty"`
+	Payment  *Payment     `json:"payment,omitempty"`
+}
+
+// ItemStatus is a new status of order item identified by chrt_id.
+type ItemStatus struct {
+	ChartID int64 `json:"chrt_id"`
+	Status  int   `json:"status"`
+}
@@ -0,0 +1,34 @@
+package order
+
+import "context"
+
+// cacheUpdatesKey is a context key of cache updates deferred until transaction is committed.
+type cacheUpdatesKey struct{}
+
+// CacheUpdates collects cache updates made by service while orders are stored in outer transaction.
+// They are applied after transaction is committed, so cache never serves order which is rolled back.
+type CacheUpdates struct {
+	updates []func(ctx context.Context)
+}
+
+// WithCacheUpdates returns ctx which makes service defer its cache updates to updates.
+func WithCacheUpdates(ctx context.Context, updates *CacheUpdates) context.Context {
+	return context.WithValue(ctx, cacheUpdatesKey{}, updates)
+}
+
+// Apply applies deferred updates in order they are made. Updates which load orders use ctx.
+func (u *CacheUpdates) Apply(ctx context.Context) {
+	for _, update := range u.updates {
+		update(ctx)
+	}
+	u.updates = nil
+}
+
+// updateCache applies update at once, or defers it if ctx is made by WithCacheUpdates.
+func updateCache(ctx context.Context, update func(ctx context.Context)) {
+	if updates, ok := ctx.Value(cacheUpdatesKey{}).(*CacheUpdates); ok {
+		updates.updates = append(updates.updates, update)
+		return
+	}
+	update(ctx)
+}
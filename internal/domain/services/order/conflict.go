@@ -0,0 +1,112 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	models "wb-L0-task/internal/domain/order"
+
+	"github.com/google/uuid"
+)
+
+// ConflictPolicy tells how order with uid which is already stored, but different payload, is handled.
+// Names of policies are also checked by Kafka config validation, so new policy must be added there.
+type ConflictPolicy string
+
+const (
+	// ConflictReject keeps stored order and rejects received one with ErrConflict.
+	ConflictReject ConflictPolicy = "reject"
+	// ConflictOverwrite replaces stored order with received one.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictNewest keeps order with the latest date_created.
+	ConflictNewest ConflictPolicy = "newest"
+)
+
+var ErrUnknownConflictPolicy = errors.New("unknown conflict policy")
+
+func (p ConflictPolicy) Validate() error {
+	switch p {
+	case ConflictReject, ConflictOverwrite, ConflictNewest:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownConflictPolicy, p)
+	}
+}
+
+// SaveResult tells what happened to received order.
+type SaveResult int
+
+const (
+	// SaveFailed means order is not saved, the reason is returned as error.
+	SaveFailed SaveResult = iota
+	// SaveCreated means order is stored for the first time.
+	SaveCreated
+	// SaveUpdated means stored order is replaced by received one.
+	SaveUpdated
+	// SaveDuplicate means identical order is already stored, e.g. message is redelivered.
+	SaveDuplicate
+	// SaveOutdated means stored order is newer than received one, so it is kept.
+	SaveOutdated
+	// SaveDeleted means stored order is deleted.
+	SaveDeleted
+)
+
+func (r SaveResult) String() string {
+	switch r {
+	case SaveFailed:
+		return "failed"
+	case SaveCreated:
+		return "created"
+	case SaveUpdated:
+		return "updated"
+	case SaveDuplicate:
+		return "duplicate"
+	case SaveOutdated:
+		return "outdated"
+	case SaveDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// SaveOutcome is a result of saving one message of a batch.
+type SaveOutcome struct {
+	Result SaveResult
+	Err    error
+}
+
+// sameOrder reports whether orders have the same payload.
+// Storage generated ids are ignored and times are compared with storage precision.
+func sameOrder(a, b *models.Order) bool {
+	return reflect.DeepEqual(normalizeOrder(a), normalizeOrder(b))
+}
+
+func normalizeOrder(order *models.Order) models.Order {
+	res := *order
+	res.DateCreated = normalizeTime(res.DateCreated)
+	res.Delivery.ID, res.Delivery.OrderUID = uuid.Nil, ""
+	res.Payment.ID, res.Payment.OrderUID = uuid.Nil, ""
+	res.Payment.PaymentDT = normalizeWallClock(res.Payment.PaymentDT)
+	res.Items = make([]models.Item, len(order.Items))
+	for i, item := range order.Items {
+		item.ID, item.OrderUID = 0, ""
+		res.Items[i] = item
+	}
+	return res
+}
+
+// normalizeTime drops location and precision which postgres does not keep.
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// normalizeWallClock converts time of column without time zone to Unix seconds of its wall clock.
+// Postgres keeps only wall clock of such time and it is read back in UTC, while decoded payment time
+// is in local time zone. Payment time is sent in Unix seconds, so finer precision is dropped.
+func normalizeWallClock(t time.Time) time.Time {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return time.Unix(wall.Unix(), 0).UTC()
+}
@@ -0,0 +1,93 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/logger"
+)
+
+// Content types of order messages.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeAvro is Avro binary in Confluent wire format: magic byte and schema id precede payload.
+	ContentTypeAvro = "application/avro"
+)
+
+// Message is a received order with content type of its value.
+type Message struct {
+	// ContentType selects decoder of value. Empty content type means JSON.
+	ContentType string
+	// SchemaVersion of JSON order payload unwrapped from envelope.
+	// Zero means bare order, which is decoded by content type.
+	SchemaVersion int
+	Value         []byte
+	// Topic and Partition tell where message is received from. They only label metrics.
+	Topic     string
+	Partition int
+}
+
+// Decoder decodes order from message value.
+// Value which can't be decoded is reported with ErrBrokenEntity.
+type Decoder interface {
+	Decode(ctx context.Context, value []byte) (*models.Order, error)
+}
+
+// Decoders is a registry of order decoders keyed by content type.
+// JSON decoder is registered by default. All decoders must be registered before messages are decoded.
+type Decoders struct {
+	decoders map[string]Decoder
+}
+
+func NewDecoders() *Decoders {
+	d := &Decoders{decoders: make(map[string]Decoder)}
+	d.Register(ContentTypeJSON, JSONDecoder{})
+	return d
+}
+
+// Register sets decoder of content type, replacing previous one.
+func (d *Decoders) Register(contentType string, decoder Decoder) {
+	d.decoders[mediaType(contentType)] = decoder
+}
+
+// Decode decodes value with decoder of content type. Unknown content type is reported with ErrBrokenEntity.
+func (d *Decoders) Decode(ctx context.Context, contentType string, value []byte) (*models.Order, error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	decoder, ok := d.decoders[mediaType(contentType)]
+	if !ok {
+		logger.Error("Unknown content type of order", "content_type", contentType)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("content type")
+	}
+	return decoder.Decode(ctx, value)
+}
+
+// mediaType drops parameters, e.g. charset, so they don't affect decoder choice.
+func mediaType(contentType string) string {
+	res, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return res
+}
+
+// JSONDecoder decodes order from JSON.
+type JSONDecoder struct{}
+
+func (JSONDecoder) Decode(_ context.Context, value []byte) (*models.Order, error) {
+	var order *models.Order
+	if err := json.Unmarshal(value, &order); err != nil {
+		logger.Error("Failed to unmarshal order", "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	if order == nil {
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	return order, nil
+}
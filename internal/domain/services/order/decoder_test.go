@@ -0,0 +1,60 @@
+package order
+
+import (
+	"context"
+	"testing"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDecoder struct {
+	order *models.Order
+}
+
+func (d stubDecoder) Decode(context.Context, []byte) (*models.Order, error) {
+	return d.order, nil
+}
+
+func TestDecoders_Decode(t *testing.T) {
+	protobufOrder := &models.Order{UID: "protobuf"}
+	decoders := NewDecoders()
+	decoders.Register(ContentTypeProtobuf, stubDecoder{order: protobufOrder})
+
+	tests := []struct {
+		name        string
+		contentType string
+		value       []byte
+		expectedUID string
+	}{
+		{name: "empty content type is JSON", value: []byte(`{"order_uid":"json"}`), expectedUID: "json"},
+		{
+			name:        "JSON with charset",
+			contentType: "application/json; charset=utf-8",
+			value:       []byte(`{"order_uid":"json"}`),
+			expectedUID: "json",
+		},
+		{name: "registered decoder", contentType: "Application/X-Protobuf", expectedUID: "protobuf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := decoders.Decode(context.Background(), tt.contentType, tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedUID, order.UID)
+		})
+	}
+}
+
+func TestDecoders_Decode_Rejected(t *testing.T) {
+	decoders := NewDecoders()
+
+	_, err := decoders.Decode(context.Background(), ContentTypeAvro, []byte{0})
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+
+	_, err = decoders.Decode(context.Background(), ContentTypeJSON, []byte("null"))
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+}
@@ -3,7 +3,9 @@ package order
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"regexp"
+	"time"
 
 	serviceErrors "wb-L0-task/internal/domain/errors"
 	models "wb-L0-task/internal/domain/order"
@@ -16,32 +18,361 @@ const (
 )
 
 type KafkaConsumerService struct {
-	storage Repository
+	storage  Repository
+	cache    Cache[models.Order]
+	negative Cache[struct{}]
+	conflict ConflictPolicy
+	decoders *Decoders
+	metrics  Metrics
 }
 
-func NewKafkaConsumerService(storage Repository) *KafkaConsumerService {
+// KafkaConsumerOptions are optional dependencies of KafkaConsumerService.
+type KafkaConsumerOptions struct {
+	// Cache, if set, receives saved orders written through.
+	Cache Cache[models.Order]
+	// Negative, if set, has saved orders removed from it.
+	Negative Cache[struct{}]
+	// Conflict decides what to do with changed order which is already stored. Default is ConflictReject.
+	Conflict ConflictPolicy
+	// Decoders, if set, decode non-JSON orders. Otherwise only JSON orders are accepted.
+	Decoders *Decoders
+	// Metrics, if set, observes outcome and duration of every saved order.
+	Metrics Metrics
+}
+
+// NewKafkaConsumerService creates consumer service which saves orders to storage.
+func NewKafkaConsumerService(storage Repository, opts KafkaConsumerOptions) *KafkaConsumerService {
+	if opts.Decoders == nil {
+		opts.Decoders = NewDecoders()
+	}
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictReject
+	}
 	return &KafkaConsumerService{
-		storage: storage,
+		storage:  storage,
+		cache:    opts.Cache,
+		negative: opts.Negative,
+		conflict: opts.Conflict,
+		decoders: opts.Decoders,
+		metrics:  opts.Metrics,
 	}
 }
 
-func (s *KafkaConsumerService) SaveOrder(ctx context.Context, message []byte) error {
-	var order *models.Order
-	var err error
-	if err = json.Unmarshal(message, &order); err != nil {
-		logger.Error("Failed to unmarshal order", "error", err)
-		return serviceErrors.ErrBrokenEntity.ForEntity("order")
-	}
+// SaveOrder decodes, validates and saves order. Redelivery of already stored order is not an error,
+// it is reported with SaveDuplicate or SaveOutdated result.
+func (s *KafkaConsumerService) SaveOrder(ctx context.Context, message Message) (SaveResult, error) {
+	start := time.Now()
+	result, err := s.saveOrder(ctx, message)
+	s.observeSave(message, Outcome(result, err), time.Since(start))
+	return result, err
+}
 
-	err = s.isValidOrder(order)
+func (s *KafkaConsumerService) saveOrder(ctx context.Context, message Message) (SaveResult, error) {
+	order, err := s.parseOrder(ctx, message)
 	if err != nil {
-		return err
+		return SaveFailed, err
 	}
 
-	err = s.storage.Save(ctx, order)
+	result, err := s.store(ctx, order)
 	if err != nil {
 		logger.Error("Failed to save order", "error", err)
-		return err
+		return SaveFailed, err
+	}
+	return result, nil
+}
+
+// SaveOrders decodes and validates every message separately and saves valid orders in one transaction.
+// Returned slice holds outcome of every message.
+// If batch can't be decoded or saved because storage or decoder is unavailable, no order is saved and the error
+// is returned. Other storage errors, including already stored orders, are isolated by saving orders one by one.
+func (s *KafkaConsumerService) SaveOrders(ctx context.Context, messages []Message) ([]SaveOutcome, error) {
+	start := time.Now()
+	outcomes, err := s.saveOrders(ctx, messages)
+	elapsed := time.Since(start)
+	for i, message := range messages {
+		// Messages of failed batch are not saved, they are reported with its error
+		outcome := Outcome(SaveFailed, err)
+		if err == nil {
+			outcome = Outcome(outcomes[i].Result, outcomes[i].Err)
+		}
+		s.observeSave(message, outcome, elapsed)
+	}
+	return outcomes, err
+}
+
+func (s *KafkaConsumerService) saveOrders(ctx context.Context, messages []Message) ([]SaveOutcome, error) {
+	outcomes := make([]SaveOutcome, len(messages))
+	orders := make([]*models.Order, 0, len(messages))
+	indexes := make([]int, 0, len(messages))
+	for i, message := range messages {
+		order, err := s.parseOrder(ctx, message)
+		if errors.Is(err, serviceErrors.ErrUnavailable) {
+			return nil, err
+		}
+		if err != nil {
+			outcomes[i] = SaveOutcome{Result: SaveFailed, Err: err}
+			continue
+		}
+		orders = append(orders, order)
+		indexes = append(indexes, i)
+	}
+	if len(orders) == 0 {
+		return outcomes, nil
+	}
+
+	err := s.storage.SaveBatch(ctx, orders)
+	if err == nil {
+		for j, order := range orders {
+			s.orderSaved(ctx, order)
+			outcomes[indexes[j]] = SaveOutcome{Result: SaveCreated}
+		}
+		return outcomes, nil
+	}
+	if errors.Is(err, serviceErrors.ErrUnavailable) {
+		logger.Error("Failed to save orders batch", "size", len(orders), "error", err)
+		return nil, err
+	}
+
+	logger.Warn("Failed to save orders batch, save orders one by one", "size", len(orders), "error", err)
+	for j, order := range orders {
+		result, err := s.store(ctx, order)
+		if errors.Is(err, serviceErrors.ErrUnavailable) {
+			return nil, err
+		}
+		if err != nil {
+			logger.Error("Failed to save order", "order_id", order.UID, "error", err)
+		}
+		outcomes[indexes[j]] = SaveOutcome{Result: result, Err: err}
+	}
+	return outcomes, nil
+}
+
+// observeSave reports outcome of message saved in elapsed time.
+func (s *KafkaConsumerService) observeSave(message Message, outcome string, elapsed time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.ObserveSave(message.Topic, message.Partition, outcome, elapsed)
+}
+
+// store saves order, resolving conflict with already stored order by conflict policy.
+func (s *KafkaConsumerService) store(ctx context.Context, order *models.Order) (SaveResult, error) {
+	err := s.storage.Save(ctx, order)
+	if err == nil {
+		s.orderSaved(ctx, order)
+		return SaveCreated, nil
+	}
+	if !errors.Is(err, serviceErrors.ErrConflict) {
+		return SaveFailed, err
+	}
+
+	stored, err := s.storage.GetById(ctx, order.UID)
+	if err != nil {
+		return SaveFailed, err
+	}
+	// Updates of stored order are never overwritten by its previous state
+	if order.Version < stored.Version {
+		logger.Info("Order of newer version is already stored, skip it", "order_id", order.UID)
+		return SaveOutdated, nil
+	}
+	if sameOrder(stored, order) {
+		logger.Info("Order is already stored, skip it", "order_id", order.UID)
+		return SaveDuplicate, nil
+	}
+
+	switch s.conflict {
+	case ConflictOverwrite:
+	case ConflictNewest:
+		if !order.DateCreated.After(stored.DateCreated) {
+			logger.Info("Newer order is already stored, skip it", "order_id", order.UID)
+			return SaveOutdated, nil
+		}
+	default:
+		return SaveFailed, serviceErrors.ErrConflict.ForEntity("order")
+	}
+
+	if err = s.storage.Replace(ctx, order); err != nil {
+		return SaveFailed, err
+	}
+	logger.Info("Stored order is replaced", "order_id", order.UID)
+	s.orderSaved(ctx, order)
+	return SaveUpdated, nil
+}
+
+// UpdateOrder parses, validates and applies order update.
+// Redelivery of already applied update is reported with SaveDuplicate result,
+// update older than stored order is rejected with ErrOutdated.
+func (s *KafkaConsumerService) UpdateOrder(ctx context.Context, message []byte) (SaveResult, error) {
+	update, err := s.parseUpdate(message)
+	if err != nil {
+		return SaveFailed, err
+	}
+	return s.applyUpdate(ctx, update)
+}
+
+// ConfirmPayment applies payment confirmation, which is an order update changing only payment.
+// It is versioned like any other update.
+func (s *KafkaConsumerService) ConfirmPayment(ctx context.Context, message []byte) (SaveResult, error) {
+	update, err := s.parseUpdate(message)
+	if err != nil {
+		return SaveFailed, err
+	}
+	if update.Payment == nil || update.Delivery != nil || len(update.Items) > 0 {
+		return SaveFailed, serviceErrors.ErrInvalidEntity.ForEntity("payment confirmation")
+	}
+	return s.applyUpdate(ctx, update)
+}
+
+// applyUpdate stores valid update and refreshes updated order in cache.
+func (s *KafkaConsumerService) applyUpdate(ctx context.Context, update *models.Update) (SaveResult, error) {
+	err := s.storage.Update(ctx, update)
+	if errors.Is(err, serviceErrors.ErrNotModified) {
+		logger.Info("Order update is already applied, skip it", "order_id", update.UID, "version", update.Version)
+		return SaveDuplicate, nil
+	}
+	if err != nil {
+		logger.Error("Failed to update order", "order_id", update.UID, "version", update.Version, "error", err)
+		return SaveFailed, err
+	}
+	s.orderUpdated(ctx, update.UID)
+	return SaveUpdated, nil
+}
+
+// DeleteOrder deletes order with all its details and evicts it from cache.
+// Order uid is taken from message, or from key if message is empty, e.g. for tombstone.
+// Deletion of order which is not stored is reported with SaveDuplicate result, as deletion may be redelivered.
+func (s *KafkaConsumerService) DeleteOrder(ctx context.Context, key, message []byte) (SaveResult, error) {
+	orderUID := string(key)
+	if len(message) > 0 {
+		var deletion struct {
+			UID string `json:"order_uid"`
+		}
+		if err := json.Unmarshal(message, &deletion); err != nil {
+			logger.Error("Failed to unmarshal order deletion", "error", err)
+			return SaveFailed, serviceErrors.ErrBrokenEntity.ForEntity("order deletion")
+		}
+		if deletion.UID != "" {
+			orderUID = deletion.UID
+		}
+	}
+	if orderUID == "" {
+		return SaveFailed, serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
+	}
+
+	err := s.storage.Delete(ctx, orderUID)
+	if errors.Is(err, serviceErrors.ErrNotFound) {
+		logger.Info("Order is not stored, nothing to delete", "order_id", orderUID)
+		s.orderDeleted(ctx, orderUID)
+		return SaveDuplicate, nil
+	}
+	if err != nil {
+		logger.Error("Failed to delete order", "order_id", orderUID, "error", err)
+		return SaveFailed, err
+	}
+	logger.Info("Order is deleted", "order_id", orderUID)
+	s.orderDeleted(ctx, orderUID)
+	return SaveDeleted, nil
+}
+
+func (s *KafkaConsumerService) parseOrder(ctx context.Context, message Message) (*models.Order, error) {
+	var order *models.Order
+	var err error
+	if message.SchemaVersion != 0 {
+		order, err = upgradeOrder(message.SchemaVersion, message.Value)
+	} else {
+		order, err = s.decoders.Decode(ctx, message.ContentType, message.Value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.isValidOrder(order); err != nil {
+		return nil, err
+	}
+	if order.Version == 0 {
+		order.Version = models.InitialVersion
+	}
+	return order, nil
+}
+
+func (s *KafkaConsumerService) parseUpdate(message []byte) (*models.Update, error) {
+	var update *models.Update
+	if err := json.Unmarshal(message, &update); err != nil {
+		logger.Error("Failed to unmarshal order update", "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order update")
+	}
+	if update == nil {
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order update")
+	}
+
+	if err := s.isValidUpdate(update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// orderSaved updates caches after order is saved.
+func (s *KafkaConsumerService) orderSaved(ctx context.Context, order *models.Order) {
+	updateCache(ctx, func(context.Context) {
+		if s.negative != nil {
+			s.negative.Delete(order.UID)
+		}
+		if s.cache != nil {
+			logger.Debug("Write order through to cache", "order_id", order.UID)
+			s.cache.Set(order.UID, *order, 0)
+		}
+	})
+}
+
+// orderUpdated refreshes caches after order is updated.
+// If updated order can't be loaded, it is evicted from cache, so stale order is not served.
+func (s *KafkaConsumerService) orderUpdated(ctx context.Context, orderUID string) {
+	if s.cache == nil {
+		return
+	}
+	updateCache(ctx, func(ctx context.Context) {
+		order, err := s.storage.GetById(ctx, orderUID)
+		if err != nil {
+			logger.Warn("Failed to load updated order, evict it from cache", "order_id", orderUID, "error", err)
+			s.cache.Delete(orderUID)
+			return
+		}
+		logger.Debug("Refresh updated order in cache", "order_id", orderUID)
+		s.cache.Set(orderUID, *order, 0)
+	})
+}
+
+// orderDeleted evicts deleted order from cache.
+// Caches of other replicas are evicted by storage change notification.
+func (s *KafkaConsumerService) orderDeleted(ctx context.Context, orderUID string) {
+	if s.cache == nil {
+		return
+	}
+	updateCache(ctx, func(context.Context) {
+		s.cache.Delete(orderUID)
+	})
+}
+
+func (s *KafkaConsumerService) isValidUpdate(update *models.Update) error {
+	if update.UID == "" {
+		return serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
+	}
+	// Update always follows creation of order, which has initial version
+	if update.Version <= models.InitialVersion {
+		return serviceErrors.ErrInvalidEntity.ForEntity("version")
+	}
+	if update.Delivery == nil && update.Payment == nil && len(update.Items) == 0 {
+		return serviceErrors.ErrInvalidEntity.ForEntity("order update")
+	}
+	if update.Delivery != nil {
+		if err := isValidDelivery(update.Delivery); err != nil {
+			return err
+		}
+	}
+	if update.Payment != nil {
+		if err := isValidPaymentAmount(update.Payment); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -51,16 +382,8 @@ func (s *KafkaConsumerService) isValidOrder(order *models.Order) error {
 		return serviceErrors.ErrInvalidEntity.ForEntity("order_uid")
 	}
 
-	// Check correct phone number
-	matched, err := regexp.MatchString(phoneNumberRegex, order.Delivery.Phone)
-	if err != nil || !matched {
-		return serviceErrors.ErrInvalidEntity.ForEntity("order.delivery.phone")
-	}
-
-	// Check correct email
-	matched, err = regexp.MatchString(emailRegex, order.Delivery.Email)
-	if err != nil || !matched {
-		return serviceErrors.ErrInvalidEntity.ForEntity("order.delivery.email")
+	if err := isValidDelivery(&order.Delivery); err != nil {
+		return err
 	}
 	// Check total sum of items with payment
 	goodsTotal := uint(0)
@@ -75,9 +398,28 @@ func (s *KafkaConsumerService) isValidOrder(order *models.Order) error {
 	if order.Payment.GoodsTotal != goodsTotal {
 		return serviceErrors.ErrInvalidEntity.ForEntity("order.payment.goods_total")
 	}
-	if order.Payment.DeliveryCost+goodsTotal+order.Payment.CustomFee != order.Payment.Amount {
-		return serviceErrors.ErrInvalidEntity.ForEntity("order.payment.goods_total")
+	return isValidPaymentAmount(&order.Payment)
+}
+
+func isValidDelivery(delivery *models.Delivery) error {
+	// Check correct phone number
+	matched, err := regexp.MatchString(phoneNumberRegex, delivery.Phone)
+	if err != nil || !matched {
+		return serviceErrors.ErrInvalidEntity.ForEntity("order.delivery.phone")
+	}
+
+	// Check correct email
+	matched, err = regexp.MatchString(emailRegex, delivery.Email)
+	if err != nil || !matched {
+		return serviceErrors.ErrInvalidEntity.ForEntity("order.delivery.email")
 	}
+	return nil
+}
 
+// isValidPaymentAmount checks that payment amount is a sum of its parts.
+func isValidPaymentAmount(payment *models.Payment) error {
+	if payment.DeliveryCost+payment.GoodsTotal+payment.CustomFee != payment.Amount {
+		return serviceErrors.ErrInvalidEntity.ForEntity("order.payment.goods_total")
+	}
 	return nil
 }
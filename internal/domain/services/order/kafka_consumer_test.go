@@ -10,6 +10,7 @@ import (
 	serviceErrors "wb-L0-task/internal/domain/errors"
 	models "wb-L0-task/internal/domain/order"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -17,10 +18,11 @@ import (
 
 func TestKafkaConsumerService_SaveOrder_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	validOrder := &models.Order{
-		UID: "test123",
+		UID:     "test123",
+		Version: 1,
 		Delivery: models.Delivery{
 			Phone: "+79161234567",
 			Email: "test@example.com",
@@ -46,7 +48,7 @@ func TestKafkaConsumerService_SaveOrder_Success(t *testing.T) {
 
 	mockRepo.On("Save", mock.Anything, validOrder).Return(nil).Once()
 
-	err = service.SaveOrder(context.Background(), orderJSON)
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -54,10 +56,11 @@ func TestKafkaConsumerService_SaveOrder_Success(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_EmptyOrderUID(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	order := &models.Order{
-		UID: "",
+		UID:     "",
+		Version: 1,
 		Delivery: models.Delivery{
 			Phone: "+79161234567",
 			Email: "test@example.com",
@@ -67,7 +70,7 @@ func TestKafkaConsumerService_SaveOrder_EmptyOrderUID(t *testing.T) {
 	orderJSON, err := json.Marshal(order)
 	require.NoError(t, err)
 
-	err = service.SaveOrder(context.Background(), orderJSON)
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -76,7 +79,7 @@ func TestKafkaConsumerService_SaveOrder_EmptyOrderUID(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_InvalidPhoneNumber(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	testCases := []struct {
 		name  string
@@ -92,7 +95,8 @@ func TestKafkaConsumerService_SaveOrder_InvalidPhoneNumber(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			order := &models.Order{
-				UID: "test123",
+				UID:     "test123",
+				Version: 1,
 				Delivery: models.Delivery{
 					Phone: tc.phone,
 					Email: "test@example.com",
@@ -102,7 +106,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidPhoneNumber(t *testing.T) {
 			orderJSON, err := json.Marshal(order)
 			require.NoError(t, err)
 
-			err = service.SaveOrder(context.Background(), orderJSON)
+			_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 			require.Error(t, err)
 			assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -113,7 +117,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidPhoneNumber(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_ValidPhoneNumbers(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	validPhones := []string{
 		"+79161234567",
@@ -126,7 +130,8 @@ func TestKafkaConsumerService_SaveOrder_ValidPhoneNumbers(t *testing.T) {
 	for _, phone := range validPhones {
 		t.Run("phone_"+phone, func(t *testing.T) {
 			order := &models.Order{
-				UID: "test123",
+				UID:     "test123",
+				Version: 1,
 				Delivery: models.Delivery{
 					Phone: phone,
 					Email: "test@example.com",
@@ -152,7 +157,7 @@ func TestKafkaConsumerService_SaveOrder_ValidPhoneNumbers(t *testing.T) {
 
 			mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
 
-			err = service.SaveOrder(context.Background(), orderJSON)
+			_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 			require.NoError(t, err)
 			mockRepo.AssertExpectations(t)
@@ -162,7 +167,7 @@ func TestKafkaConsumerService_SaveOrder_ValidPhoneNumbers(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_InvalidEmail(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	testCases := []struct {
 		name  string
@@ -179,7 +184,8 @@ func TestKafkaConsumerService_SaveOrder_InvalidEmail(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			order := &models.Order{
-				UID: "test123",
+				UID:     "test123",
+				Version: 1,
 				Delivery: models.Delivery{
 					Phone: "+79161234567",
 					Email: tc.email,
@@ -189,7 +195,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidEmail(t *testing.T) {
 			orderJSON, err := json.Marshal(order)
 			require.NoError(t, err)
 
-			err = service.SaveOrder(context.Background(), orderJSON)
+			_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 			require.Error(t, err)
 			assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -200,7 +206,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidEmail(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_ValidEmails(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	validEmails := []string{
 		"test@example.com",
@@ -214,7 +220,8 @@ func TestKafkaConsumerService_SaveOrder_ValidEmails(t *testing.T) {
 	for _, email := range validEmails {
 		t.Run("email_"+email, func(t *testing.T) {
 			order := &models.Order{
-				UID: "test123",
+				UID:     "test123",
+				Version: 1,
 				Delivery: models.Delivery{
 					Phone: "+79161234567",
 					Email: email,
@@ -240,7 +247,7 @@ func TestKafkaConsumerService_SaveOrder_ValidEmails(t *testing.T) {
 
 			mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
 
-			err = service.SaveOrder(context.Background(), orderJSON)
+			_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 			require.NoError(t, err)
 			mockRepo.AssertExpectations(t)
@@ -250,7 +257,7 @@ func TestKafkaConsumerService_SaveOrder_ValidEmails(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_InvalidItemTotalPrice(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	testCases := []struct {
 		name  string
@@ -272,7 +279,8 @@ func TestKafkaConsumerService_SaveOrder_InvalidItemTotalPrice(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			order := &models.Order{
-				UID: "test123",
+				UID:     "test123",
+				Version: 1,
 				Delivery: models.Delivery{
 					Phone: "+79161234567",
 					Email: "test@example.com",
@@ -290,7 +298,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidItemTotalPrice(t *testing.T) {
 			orderJSON, err := json.Marshal(order)
 			require.NoError(t, err)
 
-			err = service.SaveOrder(context.Background(), orderJSON)
+			_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 			require.Error(t, err)
 			assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -301,10 +309,11 @@ func TestKafkaConsumerService_SaveOrder_InvalidItemTotalPrice(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_InvalidGoodsTotal(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	order := &models.Order{
-		UID: "test123",
+		UID:     "test123",
+		Version: 1,
 		Delivery: models.Delivery{
 			Phone: "+79161234567",
 			Email: "test@example.com",
@@ -328,7 +337,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidGoodsTotal(t *testing.T) {
 	orderJSON, err := json.Marshal(order)
 	require.NoError(t, err)
 
-	err = service.SaveOrder(context.Background(), orderJSON)
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -337,10 +346,11 @@ func TestKafkaConsumerService_SaveOrder_InvalidGoodsTotal(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_InvalidPaymentAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	order := &models.Order{
-		UID: "test123",
+		UID:     "test123",
+		Version: 1,
 		Delivery: models.Delivery{
 			Phone: "+79161234567",
 			Email: "test@example.com",
@@ -364,7 +374,7 @@ func TestKafkaConsumerService_SaveOrder_InvalidPaymentAmount(t *testing.T) {
 	orderJSON, err := json.Marshal(order)
 	require.NoError(t, err)
 
-	err = service.SaveOrder(context.Background(), orderJSON)
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
@@ -373,10 +383,11 @@ func TestKafkaConsumerService_SaveOrder_InvalidPaymentAmount(t *testing.T) {
 
 func TestKafkaConsumerService_SaveOrder_MultipleItems(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	order := &models.Order{
-		UID: "test123",
+		UID:     "test123",
+		Version: 1,
 		Delivery: models.Delivery{
 			Phone: "+79161234567",
 			Email: "test@example.com",
@@ -412,7 +423,7 @@ func TestKafkaConsumerService_SaveOrder_MultipleItems(t *testing.T) {
 
 	mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
 
-	err = service.SaveOrder(context.Background(), orderJSON)
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
 
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -420,7 +431,7 @@ func TestKafkaConsumerService_SaveOrder_MultipleItems(t *testing.T) {
 
 func TestKafkaConsumerService_isValidOrder_EmptyOrder(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewKafkaConsumerService(mockRepo)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
 
 	emptyOrder := &models.Order{}
 
@@ -428,3 +439,696 @@ func TestKafkaConsumerService_isValidOrder_EmptyOrder(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, serviceErrors.ErrInvalidEntity))
 }
+
+func TestKafkaConsumerService_SaveOrder_WriteThrough(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	order := &models.Order{
+		UID:     "test123",
+		Version: 1,
+		Delivery: models.Delivery{
+			Phone: "+79161234567",
+			Email: "test@example.com",
+		},
+		Payment: models.Payment{
+			PaymentDT:    time.Now().Truncate(time.Second),
+			GoodsTotal:   1000,
+			DeliveryCost: 500,
+			Amount:       1500,
+		},
+		Items: []models.Item{{Price: 1000, TotalPrice: 1000}},
+	}
+
+	orderJSON, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
+	mockCache.On("Set", "test123", *order, time.Duration(0)).Once()
+
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_SaveOrder_WriteThrough_SaveError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	order := &models.Order{
+		UID:     "test123",
+		Version: 1,
+		Delivery: models.Delivery{
+			Phone: "+79161234567",
+			Email: "test@example.com",
+		},
+		Payment: models.Payment{
+			PaymentDT:    time.Now().Truncate(time.Second),
+			GoodsTotal:   1000,
+			DeliveryCost: 500,
+			Amount:       1500,
+		},
+		Items: []models.Item{{Price: 1000, TotalPrice: 1000}},
+	}
+
+	orderJSON, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	mockRepo.On("Save", mock.Anything, order).Return(assert.AnError).Once()
+
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
+
+	require.ErrorIs(t, err, assert.AnError)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestKafkaConsumerService_SaveOrder_ClearsNegativeCache(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockNegative := new(MockCache[struct{}])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Negative: mockNegative})
+
+	order := &models.Order{
+		UID:     "test123",
+		Version: 1,
+		Delivery: models.Delivery{
+			Phone: "+79161234567",
+			Email: "test@example.com",
+		},
+		Payment: models.Payment{
+			PaymentDT:    time.Now().Truncate(time.Second),
+			GoodsTotal:   1000,
+			DeliveryCost: 500,
+			Amount:       1500,
+		},
+		Items: []models.Item{{Price: 1000, TotalPrice: 1000}},
+	}
+
+	orderJSON, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
+	mockNegative.On("Delete", "test123").Once()
+
+	_, err = service.SaveOrder(context.Background(), Message{Value: orderJSON})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockNegative.AssertExpectations(t)
+}
+
+func validTestOrder(uid string) *models.Order {
+	return &models.Order{
+		UID:     uid,
+		Version: 1,
+		Delivery: models.Delivery{
+			Phone: "+79161234567",
+			Email: "test@example.com",
+		},
+		Payment: models.Payment{
+			PaymentDT:    time.Now().Truncate(time.Second),
+			GoodsTotal:   1000,
+			DeliveryCost: 500,
+			Amount:       1500,
+		},
+		Items: []models.Item{{Price: 1000, TotalPrice: 1000}},
+	}
+}
+
+func marshalOrders(t *testing.T, orders ...*models.Order) []Message {
+	t.Helper()
+	messages := make([]Message, len(orders))
+	for i, order := range orders {
+		orderJSON, err := json.Marshal(order)
+		require.NoError(t, err)
+		messages[i] = Message{Value: orderJSON}
+	}
+	return messages
+}
+
+func TestKafkaConsumerService_SaveOrders_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	first, second := validTestOrder("order1"), validTestOrder("order2")
+
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{first, second}).Return(nil).Once()
+	mockCache.On("Set", "order1", *first, time.Duration(0)).Once()
+	mockCache.On("Set", "order2", *second, time.Duration(0)).Once()
+
+	outcomes, err := service.SaveOrders(context.Background(), marshalOrders(t, first, second))
+
+	require.NoError(t, err)
+	assert.Equal(t, []SaveOutcome{{Result: SaveCreated}, {Result: SaveCreated}}, outcomes)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_SaveOrders_InvalidMessagesAreIsolated(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+	valid := validTestOrder("order1")
+	invalid := validTestOrder("order2")
+	invalid.Delivery.Email = "invalid"
+	messages := marshalOrders(t, invalid, valid)
+	messages = append(messages, Message{Value: []byte("{broken")})
+
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{valid}).Return(nil).Once()
+
+	outcomes, err := service.SaveOrders(context.Background(), messages)
+
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, SaveFailed, outcomes[0].Result)
+	assert.ErrorIs(t, outcomes[0].Err, serviceErrors.ErrInvalidEntity)
+	assert.Equal(t, SaveOutcome{Result: SaveCreated}, outcomes[1])
+	assert.Equal(t, SaveFailed, outcomes[2].Result)
+	assert.ErrorIs(t, outcomes[2].Err, serviceErrors.ErrBrokenEntity)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_SaveOrders_AllInvalid(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+	outcomes, err := service.SaveOrders(context.Background(), []Message{
+		{Value: []byte("null")},
+		{Value: []byte("{")},
+	})
+
+	require.NoError(t, err)
+	assert.ErrorIs(t, outcomes[0].Err, serviceErrors.ErrBrokenEntity)
+	assert.ErrorIs(t, outcomes[1].Err, serviceErrors.ErrBrokenEntity)
+	mockRepo.AssertNotCalled(t, "SaveBatch")
+}
+
+func TestKafkaConsumerService_SaveOrders_StorageUnavailable(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	order := validTestOrder("order1")
+	unavailable := serviceErrors.ErrUnavailable.ForEntity("storage")
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{order}).Return(unavailable).Once()
+
+	outcomes, err := service.SaveOrders(context.Background(), marshalOrders(t, order))
+
+	require.ErrorIs(t, err, serviceErrors.ErrUnavailable)
+	assert.Nil(t, outcomes)
+	mockRepo.AssertNotCalled(t, "Save")
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestKafkaConsumerService_SaveOrders_FallbackToSingleSaves(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+	first, second, third := validTestOrder("order1"), validTestOrder("order2"), validTestOrder("order3")
+	conflict := serviceErrors.ErrConflict.ForEntity("order")
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{first, second, third}).Return(assert.AnError).Once()
+	mockRepo.On("Save", mock.Anything, first).Return(conflict).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(validTestOrder("order1"), nil).Once()
+	mockRepo.On("Save", mock.Anything, second).Return(nil).Once()
+	mockRepo.On("Save", mock.Anything, third).Return(errors.New("unique violation")).Once()
+
+	outcomes, err := service.SaveOrders(context.Background(), marshalOrders(t, first, second, third))
+
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, SaveOutcome{Result: SaveDuplicate}, outcomes[0])
+	assert.Equal(t, SaveOutcome{Result: SaveCreated}, outcomes[1])
+	assert.Equal(t, SaveFailed, outcomes[2].Result)
+	assert.EqualError(t, outcomes[2].Err, "unique violation")
+	mockRepo.AssertExpectations(t)
+}
+
+// storedOrder returns order as it is loaded from storage: with generated ids and times in other location.
+func storedOrder(order *models.Order) *models.Order {
+	stored := *order
+	stored.DateCreated = order.DateCreated.In(time.FixedZone("MSK", 3*60*60))
+	stored.Delivery.ID = uuid.New()
+	stored.Delivery.OrderUID = order.UID
+	stored.Payment.ID = uuid.New()
+	stored.Payment.OrderUID = order.UID
+	stored.Items = make([]models.Item, len(order.Items))
+	for i, item := range order.Items {
+		item.ID = i + 1
+		item.OrderUID = order.UID
+		stored.Items[i] = item
+	}
+	return &stored
+}
+
+func TestKafkaConsumerService_SaveOrder_Duplicate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache, Conflict: ConflictOverwrite})
+
+	order := validTestOrder("order1")
+	order.DateCreated = time.Now().UTC()
+	mockRepo.On("Save", mock.Anything, order).Return(serviceErrors.ErrConflict.ForEntity("order")).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(storedOrder(order), nil).Once()
+
+	result, err := service.SaveOrder(context.Background(), marshalOrders(t, order)[0])
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveDuplicate, result)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Replace")
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestSameOrder_PaymentTimeWithoutZone(t *testing.T) {
+	order := validTestOrder("order1")
+	order.Payment.PaymentDT = time.Unix(1754006400, 0).In(time.FixedZone("MSK", 3*60*60))
+	// Payment time is read back with wall clock of written one
+	stored := storedOrder(order)
+	stored.Payment.PaymentDT = time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC)
+	changed := storedOrder(order)
+	changed.Payment.PaymentDT = stored.Payment.PaymentDT.Add(time.Second)
+
+	assert.True(t, sameOrder(stored, order))
+	assert.False(t, sameOrder(changed, order))
+}
+
+func TestKafkaConsumerService_SaveOrder_Conflict(t *testing.T) {
+	older := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		policy       ConflictPolicy
+		storedDate   time.Time
+		receivedDate time.Time
+		wantResult   SaveResult
+		wantErr      error
+		wantReplace  bool
+	}{
+		{name: "reject", policy: ConflictReject, storedDate: older, receivedDate: newer,
+			wantResult: SaveFailed, wantErr: serviceErrors.ErrConflict},
+		{name: "overwrite older", policy: ConflictOverwrite, storedDate: newer, receivedDate: older,
+			wantResult: SaveUpdated, wantReplace: true},
+		{name: "newest received", policy: ConflictNewest, storedDate: older, receivedDate: newer,
+			wantResult: SaveUpdated, wantReplace: true},
+		{name: "newest stored", policy: ConflictNewest, storedDate: newer, receivedDate: older,
+			wantResult: SaveOutdated},
+		{name: "newest same date", policy: ConflictNewest, storedDate: older, receivedDate: older,
+			wantResult: SaveOutdated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[models.Order])
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache, Conflict: tt.policy})
+
+			stored := validTestOrder("order1")
+			stored.DateCreated = tt.storedDate
+			stored.TrackNumber = "stored"
+			received := validTestOrder("order1")
+			received.DateCreated = tt.receivedDate
+			received.TrackNumber = "received"
+
+			mockRepo.On("Save", mock.Anything, received).Return(serviceErrors.ErrConflict.ForEntity("order")).Once()
+			mockRepo.On("GetById", mock.Anything, "order1").Return(storedOrder(stored), nil).Once()
+			if tt.wantReplace {
+				mockRepo.On("Replace", mock.Anything, received).Return(nil).Once()
+				mockCache.On("Set", "order1", *received, time.Duration(0)).Once()
+			}
+
+			result, err := service.SaveOrder(context.Background(), marshalOrders(t, received)[0])
+
+			assert.Equal(t, tt.wantResult, result)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+			if !tt.wantReplace {
+				mockRepo.AssertNotCalled(t, "Replace")
+			}
+		})
+	}
+}
+
+func TestKafkaConsumerService_SaveOrder_ConflictLoadError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Conflict: ConflictOverwrite})
+
+	order := validTestOrder("order1")
+	mockRepo.On("Save", mock.Anything, order).Return(serviceErrors.ErrConflict.ForEntity("order")).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(nil, assert.AnError).Once()
+
+	result, err := service.SaveOrder(context.Background(), marshalOrders(t, order)[0])
+
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, SaveFailed, result)
+}
+
+func TestConflictPolicy_Validate(t *testing.T) {
+	for _, policy := range []ConflictPolicy{ConflictReject, ConflictOverwrite, ConflictNewest} {
+		assert.NoError(t, policy.Validate())
+	}
+	require.ErrorIs(t, ConflictPolicy("ignore").Validate(), ErrUnknownConflictPolicy)
+	require.ErrorIs(t, ConflictPolicy("").Validate(), ErrUnknownConflictPolicy)
+}
+
+func marshalUpdate(t *testing.T, update *models.Update) []byte {
+	t.Helper()
+	updateJSON, err := json.Marshal(update)
+	require.NoError(t, err)
+	return updateJSON
+}
+
+func TestKafkaConsumerService_UpdateOrder_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	update := &models.Update{
+		UID:      "order1",
+		Version:  2,
+		Items:    []models.ItemStatus{{ChartID: 9934930, Status: 203}},
+		Delivery: &models.Delivery{Phone: "+79161234567", Email: "new@example.com", Address: "Ploshad Mira 15"},
+	}
+	updated := validTestOrder("order1")
+	updated.Version = 2
+	mockRepo.On("Update", mock.Anything, update).Return(nil).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(updated, nil).Once()
+	mockCache.On("Set", "order1", *updated, time.Duration(0)).Once()
+
+	result, err := service.UpdateOrder(context.Background(), marshalUpdate(t, update))
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveUpdated, result)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_UpdateOrder_ReloadFailed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+	update := &models.Update{UID: "order1", Version: 2, Items: []models.ItemStatus{{ChartID: 1, Status: 203}}}
+	mockRepo.On("Update", mock.Anything, update).Return(nil).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(nil, assert.AnError).Once()
+	mockCache.On("Delete", "order1").Once()
+
+	result, err := service.UpdateOrder(context.Background(), marshalUpdate(t, update))
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveUpdated, result)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestKafkaConsumerService_UpdateOrder_Version(t *testing.T) {
+	tests := []struct {
+		name       string
+		storageErr error
+		wantResult SaveResult
+		wantErr    error
+	}{
+		{"already applied", serviceErrors.ErrNotModified.ForEntity("order"), SaveDuplicate, nil},
+		{"stale", serviceErrors.ErrOutdated.ForEntity("order version"), SaveFailed, serviceErrors.ErrOutdated},
+		{"unknown order", serviceErrors.ErrNotFound.ForEntity("order"), SaveFailed, serviceErrors.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[models.Order])
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+
+			update := &models.Update{UID: "order1", Version: 3, Items: []models.ItemStatus{{ChartID: 1, Status: 203}}}
+			mockRepo.On("Update", mock.Anything, update).Return(tt.storageErr).Once()
+
+			result, err := service.UpdateOrder(context.Background(), marshalUpdate(t, update))
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantResult, result)
+			mockRepo.AssertNotCalled(t, "GetById")
+			mockCache.AssertNotCalled(t, "Set")
+		})
+	}
+}
+
+func TestKafkaConsumerService_UpdateOrder_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		wantErr error
+	}{
+		{"broken", `{"order_uid":`, serviceErrors.ErrBrokenEntity},
+		{"null", `null`, serviceErrors.ErrBrokenEntity},
+		{"empty uid", `{"version":2,"items":[{"chrt_id":1,"status":203}]}`, serviceErrors.ErrInvalidEntity},
+		{"initial version", `{"order_uid":"order1","version":1,"items":[{"chrt_id":1,"status":203}]}`,
+			serviceErrors.ErrInvalidEntity},
+		{"nothing changed", `{"order_uid":"order1","version":2}`, serviceErrors.ErrInvalidEntity},
+		{"invalid phone", `{"order_uid":"order1","version":2,"delivery":{"phone":"abc","email":"a@b.cd"}}`,
+			serviceErrors.ErrInvalidEntity},
+		{"invalid amount", `{"order_uid":"order1","version":2,"payment":{"amount":10,"goods_total":5}}`,
+			serviceErrors.ErrInvalidEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+			result, err := service.UpdateOrder(context.Background(), []byte(tt.message))
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, SaveFailed, result)
+			mockRepo.AssertNotCalled(t, "Update")
+		})
+	}
+}
+
+func TestKafkaConsumerService_SaveOrder_OlderThanStoredVersion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Conflict: ConflictOverwrite})
+
+	order := validTestOrder("order1")
+	stored := storedOrder(order)
+	stored.Version = 3
+	stored.Delivery.Address = "Ploshad Mira 15"
+	mockRepo.On("Save", mock.Anything, order).Return(serviceErrors.ErrConflict.ForEntity("order")).Once()
+	mockRepo.On("GetById", mock.Anything, "order1").Return(stored, nil).Once()
+
+	// Redelivered creation must not overwrite updates applied since
+	result, err := service.SaveOrder(context.Background(), marshalOrders(t, order)[0])
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveOutdated, result)
+	mockRepo.AssertNotCalled(t, "Replace")
+}
+
+func TestKafkaConsumerService_SaveOrder_DefaultVersion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+	order := validTestOrder("order1")
+	order.Version = 0
+	mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *models.Order) bool {
+		return o.Version == models.InitialVersion
+	})).Return(nil).Once()
+
+	_, err := service.SaveOrder(context.Background(), marshalOrders(t, order)[0])
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_DeleteOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		message string
+		wantUID string
+	}{
+		{"tombstone", "order1", "", "order1"},
+		{"delete event", "", `{"order_uid":"order1"}`, "order1"},
+		{"delete event with key", "order1", `{}`, "order1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[models.Order])
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+			mockRepo.On("Delete", mock.Anything, tt.wantUID).Return(nil).Once()
+			mockCache.On("Delete", tt.wantUID).Once()
+
+			result, err := service.DeleteOrder(context.Background(), []byte(tt.key), []byte(tt.message))
+
+			require.NoError(t, err)
+			assert.Equal(t, SaveDeleted, result)
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestKafkaConsumerService_DeleteOrder_NotStored(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[models.Order])
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+	mockRepo.On("Delete", mock.Anything, "order1").Return(serviceErrors.ErrNotFound.ForEntity("order")).Once()
+	mockCache.On("Delete", "order1").Once()
+
+	// Redelivered tombstone is not an error
+	result, err := service.DeleteOrder(context.Background(), []byte("order1"), nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveDuplicate, result)
+	mockCache.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_DeleteOrder_Failed(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		message    string
+		storageErr error
+		wantErr    error
+	}{
+		{"broken", "order1", `{"order_uid":`, nil, serviceErrors.ErrBrokenEntity},
+		{"no uid", "", "", nil, serviceErrors.ErrInvalidEntity},
+		{"storage unavailable", "order1", "", serviceErrors.ErrUnavailable.ForEntity("storage"),
+			serviceErrors.ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[models.Order])
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Cache: mockCache})
+			if tt.storageErr != nil {
+				mockRepo.On("Delete", mock.Anything, tt.key).Return(tt.storageErr).Once()
+			}
+
+			result, err := service.DeleteOrder(context.Background(), []byte(tt.key), []byte(tt.message))
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, SaveFailed, result)
+			mockCache.AssertNotCalled(t, "Delete", mock.Anything)
+		})
+	}
+}
+
+func TestKafkaConsumerService_SaveOrder_SchemaVersions(t *testing.T) {
+	tests := []struct {
+		name            string
+		schemaVersion   int
+		payloadVersion  int64
+		expectedVersion int64
+	}{
+		{name: "v1 has no order version", schemaVersion: 1, payloadVersion: 3, expectedVersion: models.InitialVersion},
+		{name: "v2 keeps order version", schemaVersion: CurrentSchemaVersion, payloadVersion: 3, expectedVersion: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+			order := validTestOrder("order1")
+			order.Version = tt.payloadVersion
+			message := marshalOrders(t, order)[0]
+			message.SchemaVersion = tt.schemaVersion
+			mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *models.Order) bool {
+				return o.UID == "order1" && o.Version == tt.expectedVersion
+			})).Return(nil).Once()
+
+			result, err := service.SaveOrder(context.Background(), message)
+
+			require.NoError(t, err)
+			assert.Equal(t, SaveCreated, result)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestKafkaConsumerService_SaveOrder_SchemaVersionRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		message Message
+	}{
+		{name: "unknown schema version", message: Message{SchemaVersion: CurrentSchemaVersion + 1, Value: []byte(`{}`)}},
+		{name: "missing payload", message: Message{SchemaVersion: CurrentSchemaVersion}},
+		{name: "null payload", message: Message{SchemaVersion: 1, Value: []byte("null")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+			result, err := service.SaveOrder(context.Background(), tt.message)
+
+			require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+			assert.Equal(t, SaveFailed, result)
+			mockRepo.AssertNotCalled(t, "Save")
+		})
+	}
+}
+
+func TestKafkaConsumerService_ConfirmPayment(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+	update := &models.Update{
+		UID:     "order1",
+		Version: 3,
+		Payment: &models.Payment{
+			TransactionID: "order1",
+			PaymentDT:     time.Unix(1637907727, 0),
+			Amount:        1500,
+			GoodsTotal:    1000,
+			DeliveryCost:  500,
+		},
+	}
+	mockRepo.On("Update", mock.Anything, update).Return(nil).Once()
+
+	result, err := service.ConfirmPayment(context.Background(), marshalUpdate(t, update))
+
+	require.NoError(t, err)
+	assert.Equal(t, SaveUpdated, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestKafkaConsumerService_ConfirmPayment_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"without payment", `{"order_uid":"order1","version":2,"items":[{"chrt_id":1,"status":203}]}`},
+		{"with items", `{"order_uid":"order1","version":2,"items":[{"chrt_id":1,"status":203}],` +
+			`"payment":{"amount":15,"goods_total":10,"delivery_cost":5}}`},
+		{"initial version", `{"order_uid":"order1","version":1,"payment":{"amount":15,"goods_total":10,"delivery_cost":5}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{})
+
+			result, err := service.ConfirmPayment(context.Background(), []byte(tt.message))
+
+			require.ErrorIs(t, err, serviceErrors.ErrInvalidEntity)
+			assert.Equal(t, SaveFailed, result)
+			mockRepo.AssertNotCalled(t, "Update")
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package order
+
+import (
+	"errors"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+)
+
+// Outcomes of received messages reported to metrics.
+const (
+	OutcomeSaved        = "saved"
+	OutcomeInvalid      = "invalid"
+	OutcomeBroken       = "broken"
+	OutcomeDuplicate    = "duplicate"
+	OutcomeStorageError = "storage_error"
+)
+
+// Metrics observes saves of received orders by topic and partition they are received from.
+type Metrics interface {
+	ObserveSave(topic string, partition int, outcome string, elapsed time.Duration)
+}
+
+// Outcome classifies result of message processing. Order which is not saved because stored one is the same
+// or newer is a duplicate. Errors of messages which can't be saved are invalid or broken, other errors are
+// storage errors.
+func Outcome(result SaveResult, err error) string {
+	switch {
+	case isBroken(err):
+		return OutcomeBroken
+	case errors.Is(err, serviceErrors.ErrInvalidEntity),
+		errors.Is(err, serviceErrors.ErrConflict),
+		errors.Is(err, serviceErrors.ErrOutdated),
+		errors.Is(err, serviceErrors.ErrNotFound):
+		return OutcomeInvalid
+	case err != nil || result == SaveFailed:
+		return OutcomeStorageError
+	case result == SaveDuplicate || result == SaveOutdated:
+		return OutcomeDuplicate
+	default:
+		return OutcomeSaved
+	}
+}
+
+// isBroken tells if err is ErrBrokenEntity. Errors are matched by code, which is shared with ErrInvalidEntity,
+// so template is compared.
+func isBroken(err error) bool {
+	var entityErr *serviceErrors.EntityError
+	return errors.As(err, &entityErr) && entityErr.Template == serviceErrors.ErrBrokenEntity.Template
+}
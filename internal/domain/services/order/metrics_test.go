@@ -0,0 +1,100 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type observedSave struct {
+	topic     string
+	partition int
+	outcome   string
+}
+
+type recordingMetrics struct {
+	saves []observedSave
+}
+
+func (m *recordingMetrics) ObserveSave(topic string, partition int, outcome string, _ time.Duration) {
+	m.saves = append(m.saves, observedSave{topic: topic, partition: partition, outcome: outcome})
+}
+
+func TestOutcome(t *testing.T) {
+	tests := map[string]struct {
+		result SaveResult
+		err    error
+		want   string
+	}{
+		"created":     {result: SaveCreated, want: OutcomeSaved},
+		"updated":     {result: SaveUpdated, want: OutcomeSaved},
+		"deleted":     {result: SaveDeleted, want: OutcomeSaved},
+		"duplicate":   {result: SaveDuplicate, want: OutcomeDuplicate},
+		"outdated":    {result: SaveOutdated, want: OutcomeDuplicate},
+		"invalid":     {err: serviceErrors.ErrInvalidEntity.ForEntity("order"), want: OutcomeInvalid},
+		"conflict":    {err: serviceErrors.ErrConflict.ForEntity("order"), want: OutcomeInvalid},
+		"not found":   {err: serviceErrors.ErrNotFound.ForEntity("order"), want: OutcomeInvalid},
+		"broken":      {err: serviceErrors.ErrBrokenEntity.ForEntity("order"), want: OutcomeBroken},
+		"unavailable": {err: serviceErrors.ErrUnavailable.ForEntity("storage"), want: OutcomeStorageError},
+		"unexpected":  {err: errors.New("duplicate key"), want: OutcomeStorageError},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Outcome(tt.result, tt.err))
+		})
+	}
+}
+
+func TestKafkaConsumerService_SaveOrder_ObservesSave(t *testing.T) {
+	mockRepo := new(MockRepository)
+	metrics := &recordingMetrics{}
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Metrics: metrics})
+
+	order := validTestOrder("order1")
+	mockRepo.On("Save", mock.Anything, order).Return(nil).Once()
+	message := marshalOrders(t, order)[0]
+	message.Topic, message.Partition = "orders", 3
+
+	_, err := service.SaveOrder(context.Background(), message)
+	require.NoError(t, err)
+	_, err = service.SaveOrder(context.Background(), Message{Topic: "orders", Partition: 3, Value: []byte("{")})
+	require.ErrorIs(t, err, serviceErrors.ErrBrokenEntity)
+
+	assert.Equal(t, []observedSave{
+		{topic: "orders", partition: 3, outcome: OutcomeSaved},
+		{topic: "orders", partition: 3, outcome: OutcomeBroken},
+	}, metrics.saves)
+}
+
+func TestKafkaConsumerService_SaveOrders_ObservesEveryMessage(t *testing.T) {
+	mockRepo := new(MockRepository)
+	metrics := &recordingMetrics{}
+	service := NewKafkaConsumerService(mockRepo, KafkaConsumerOptions{Metrics: metrics})
+
+	order := validTestOrder("order1")
+	messages := append(marshalOrders(t, order), Message{Value: []byte("{")})
+	messages[0].Partition, messages[1].Partition = 0, 1
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{order}).Return(nil).Once()
+	mockRepo.On("SaveBatch", mock.Anything, []*models.Order{order}).
+		Return(serviceErrors.ErrUnavailable.ForEntity("storage")).Once()
+
+	_, err := service.SaveOrders(context.Background(), messages)
+	require.NoError(t, err)
+	_, err = service.SaveOrders(context.Background(), messages)
+	require.ErrorIs(t, err, serviceErrors.ErrUnavailable)
+
+	assert.Equal(t, []observedSave{
+		{partition: 0, outcome: OutcomeSaved},
+		{partition: 1, outcome: OutcomeBroken},
+		{partition: 0, outcome: OutcomeStorageError},
+		{partition: 1, outcome: OutcomeStorageError},
+	}, metrics.saves)
+}
@@ -37,6 +37,79 @@ func (_m *MockCache[T]) EXPECT() *MockCache_Expecter[T] {
 	return &MockCache_Expecter[T]{mock: &_m.Mock}
 }
 
+// Delete provides a mock function for the type MockCache
+func (_mock *MockCache[T]) Delete(k string) {
+	_mock.Called(k)
+	return
+}
+
+// MockCache_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockCache_Delete_Call[T any] struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - k string
+func (_e *MockCache_Expecter[T]) Delete(k interface{}) *MockCache_Delete_Call[T] {
+	return &MockCache_Delete_Call[T]{Call: _e.mock.On("Delete", k)}
+}
+
+func (_c *MockCache_Delete_Call[T]) Run(run func(k string)) *MockCache_Delete_Call[T] {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCache_Delete_Call[T]) Return() *MockCache_Delete_Call[T] {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockCache_Delete_Call[T]) RunAndReturn(run func(k string)) *MockCache_Delete_Call[T] {
+	_c.Run(run)
+	return _c
+}
+
+// Flush provides a mock function for the type MockCache
+func (_mock *MockCache[T]) Flush() {
+	_mock.Called()
+	return
+}
+
+// MockCache_Flush_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Flush'
+type MockCache_Flush_Call[T any] struct {
+	*mock.Call
+}
+
+// Flush is a helper method to define mock.On call
+func (_e *MockCache_Expecter[T]) Flush() *MockCache_Flush_Call[T] {
+	return &MockCache_Flush_Call[T]{Call: _e.mock.On("Flush")}
+}
+
+func (_c *MockCache_Flush_Call[T]) Run(run func()) *MockCache_Flush_Call[T] {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockCache_Flush_Call[T]) Return() *MockCache_Flush_Call[T] {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockCache_Flush_Call[T]) RunAndReturn(run func()) *MockCache_Flush_Call[T] {
+	_c.Run(run)
+	return _c
+}
+
 // Get provides a mock function for the type MockCache
 func (_mock *MockCache[T]) Get(k string) (T, bool) {
 	ret := _mock.Called(k)
@@ -99,6 +172,68 @@ func (_c *MockCache_Get_Call[T]) RunAndReturn(run func(k string) (T, bool)) *Moc
 	return _c
 }
 
+// GetStale provides a mock function for the type MockCache
+func (_mock *MockCache[T]) GetStale(k string) (T, bool) {
+	ret := _mock.Called(k)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStale")
+	}
+
+	var r0 T
+	var r1 bool
+	if returnFunc, ok := ret.Get(0).(func(string) (T, bool)); ok {
+		return returnFunc(k)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) T); ok {
+		r0 = returnFunc(k)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(T)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = returnFunc(k)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+	return r0, r1
+}
+
+// MockCache_GetStale_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStale'
+type MockCache_GetStale_Call[T any] struct {
+	*mock.Call
+}
+
+// GetStale is a helper method to define mock.On call
+//   - k string
+func (_e *MockCache_Expecter[T]) GetStale(k interface{}) *MockCache_GetStale_Call[T] {
+	return &MockCache_GetStale_Call[T]{Call: _e.mock.On("GetStale", k)}
+}
+
+func (_c *MockCache_GetStale_Call[T]) Run(run func(k string)) *MockCache_GetStale_Call[T] {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCache_GetStale_Call[T]) Return(v T, b bool) *MockCache_GetStale_Call[T] {
+	_c.Call.Return(v, b)
+	return _c
+}
+
+func (_c *MockCache_GetStale_Call[T]) RunAndReturn(run func(k string) (T, bool)) *MockCache_GetStale_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Set provides a mock function for the type MockCache
 func (_mock *MockCache[T]) Set(k string, v T, expiration time.Duration) {
 	_mock.Called(k, v, expiration)
@@ -6,6 +6,7 @@ package order
 
 import (
 	"context"
+	"time"
 	"wb-L0-task/internal/domain/order"
 
 	mock "github.com/stretchr/testify/mock"
@@ -38,6 +39,63 @@ func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
 	return &MockRepository_Expecter{mock: &_m.Mock}
 }
 
+// Delete provides a mock function for the type MockRepository
+func (_mock *MockRepository) Delete(ctx context.Context, orderUID string) error {
+	ret := _mock.Called(ctx, orderUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, orderUID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepository_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockRepository_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - orderUID string
+func (_e *MockRepository_Expecter) Delete(ctx interface{}, orderUID interface{}) *MockRepository_Delete_Call {
+	return &MockRepository_Delete_Call{Call: _e.mock.On("Delete", ctx, orderUID)}
+}
+
+func (_c *MockRepository_Delete_Call) Run(run func(ctx context.Context, orderUID string)) *MockRepository_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_Delete_Call) Return(err error) *MockRepository_Delete_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepository_Delete_Call) RunAndReturn(run func(ctx context.Context, orderUID string) error) *MockRepository_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Exists provides a mock function for the type MockRepository
 func (_mock *MockRepository) Exists(ctx context.Context, orderUID string) (bool, error) {
 	ret := _mock.Called(ctx, orderUID)
@@ -172,123 +230,124 @@ func (_c *MockRepository_GetById_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
-// GetOrderDelivery provides a mock function for the type MockRepository
-func (_mock *MockRepository) GetOrderDelivery(ctx context.Context, orderUID string) (*order.Delivery, error) {
-	ret := _mock.Called(ctx, orderUID)
+// GetLatestOrders provides a mock function for the type MockRepository
+func (_mock *MockRepository) GetLatestOrders(ctx context.Context, createdAfter time.Time, after *order.Order, limit int32) ([]order.Order, error) {
+	ret := _mock.Called(ctx, createdAfter, after, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrderDelivery")
+		panic("no return value specified for GetLatestOrders")
 	}
 
-	var r0 *order.Delivery
+	var r0 []order.Order
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*order.Delivery, error)); ok {
-		return returnFunc(ctx, orderUID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time, *order.Order, int32) ([]order.Order, error)); ok {
+		return returnFunc(ctx, createdAfter, after, limit)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *order.Delivery); ok {
-		r0 = returnFunc(ctx, orderUID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time, *order.Order, int32) []order.Order); ok {
+		r0 = returnFunc(ctx, createdAfter, after, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*order.Delivery)
+			r0 = ret.Get(0).([]order.Order)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, orderUID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time, *order.Order, int32) error); ok {
+		r1 = returnFunc(ctx, createdAfter, after, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockRepository_GetOrderDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderDelivery'
-type MockRepository_GetOrderDelivery_Call struct {
+// MockRepository_GetLatestOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLatestOrders'
+type MockRepository_GetLatestOrders_Call struct {
 	*mock.Call
 }
 
-// GetOrderDelivery is a helper method to define mock.On call
+// GetLatestOrders is a helper method to define mock.On call
 //   - ctx context.Context
-//   - orderUID string
-func (_e *MockRepository_Expecter) GetOrderDelivery(ctx interface{}, orderUID interface{}) *MockRepository_GetOrderDelivery_Call {
-	return &MockRepository_GetOrderDelivery_Call{Call: _e.mock.On("GetOrderDelivery", ctx, orderUID)}
+//   - createdAfter time.Time
+//   - after *order.Order
+//   - limit int32
+func (_e *MockRepository_Expecter) GetLatestOrders(ctx interface{}, createdAfter interface{}, after interface{}, limit interface{}) *MockRepository_GetLatestOrders_Call {
+	return &MockRepository_GetLatestOrders_Call{Call: _e.mock.On("GetLatestOrders", ctx, createdAfter, after, limit)}
 }
 
-func (_c *MockRepository_GetOrderDelivery_Call) Run(run func(ctx context.Context, orderUID string)) *MockRepository_GetOrderDelivery_Call {
+func (_c *MockRepository_GetLatestOrders_Call) Run(run func(ctx context.Context, createdAfter time.Time, after *order.Order, limit int32)) *MockRepository_GetLatestOrders_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 time.Time
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(time.Time)
+		}
+		var arg2 *order.Order
+		if args[2] != nil {
+			arg2 = args[2].(*order.Order)
+		}
+		var arg3 int32
+		if args[3] != nil {
+			arg3 = args[3].(int32)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockRepository_GetOrderDelivery_Call) Return(delivery *order.Delivery, err error) *MockRepository_GetOrderDelivery_Call {
-	_c.Call.Return(delivery, err)
+func (_c *MockRepository_GetLatestOrders_Call) Return(orders []order.Order, err error) *MockRepository_GetLatestOrders_Call {
+	_c.Call.Return(orders, err)
 	return _c
 }
 
-func (_c *MockRepository_GetOrderDelivery_Call) RunAndReturn(run func(ctx context.Context, orderUID string) (*order.Delivery, error)) *MockRepository_GetOrderDelivery_Call {
+func (_c *MockRepository_GetLatestOrders_Call) RunAndReturn(run func(ctx context.Context, createdAfter time.Time, after *order.Order, limit int32) ([]order.Order, error)) *MockRepository_GetLatestOrders_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetOrderItems provides a mock function for the type MockRepository
-func (_mock *MockRepository) GetOrderItems(ctx context.Context, orderUID string) ([]order.Item, error) {
-	ret := _mock.Called(ctx, orderUID)
+// Replace provides a mock function for the type MockRepository
+func (_mock *MockRepository) Replace(ctx context.Context, order1 *order.Order) error {
+	ret := _mock.Called(ctx, order1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrderItems")
+		panic("no return value specified for Replace")
 	}
 
-	var r0 []order.Item
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]order.Item, error)); ok {
-		return returnFunc(ctx, orderUID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []order.Item); ok {
-		r0 = returnFunc(ctx, orderUID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]order.Item)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, orderUID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *order.Order) error); ok {
+		r0 = returnFunc(ctx, order1)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// MockRepository_GetOrderItems_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderItems'
-type MockRepository_GetOrderItems_Call struct {
+// MockRepository_Replace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Replace'
+type MockRepository_Replace_Call struct {
 	*mock.Call
 }
 
-// GetOrderItems is a helper method to define mock.On call
+// Replace is a helper method to define mock.On call
 //   - ctx context.Context
-//   - orderUID string
-func (_e *MockRepository_Expecter) GetOrderItems(ctx interface{}, orderUID interface{}) *MockRepository_GetOrderItems_Call {
-	return &MockRepository_GetOrderItems_Call{Call: _e.mock.On("GetOrderItems", ctx, orderUID)}
+//   - order1 *order.Order
+func (_e *MockRepository_Expecter) Replace(ctx interface{}, order1 interface{}) *MockRepository_Replace_Call {
+	return &MockRepository_Replace_Call{Call: _e.mock.On("Replace", ctx, order1)}
 }
 
-func (_c *MockRepository_GetOrderItems_Call) Run(run func(ctx context.Context, orderUID string)) *MockRepository_GetOrderItems_Call {
+func (_c *MockRepository_Replace_Call) Run(run func(ctx context.Context, order1 *order.Order)) *MockRepository_Replace_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *order.Order
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*order.Order)
 		}
 		run(
 			arg0,
@@ -298,65 +357,54 @@ func (_c *MockRepository_GetOrderItems_Call) Run(run func(ctx context.Context, o
 	return _c
 }
 
-func (_c *MockRepository_GetOrderItems_Call) Return(items []order.Item, err error) *MockRepository_GetOrderItems_Call {
-	_c.Call.Return(items, err)
+func (_c *MockRepository_Replace_Call) Return(err error) *MockRepository_Replace_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockRepository_GetOrderItems_Call) RunAndReturn(run func(ctx context.Context, orderUID string) ([]order.Item, error)) *MockRepository_GetOrderItems_Call {
+func (_c *MockRepository_Replace_Call) RunAndReturn(run func(ctx context.Context, order1 *order.Order) error) *MockRepository_Replace_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetOrderPayment provides a mock function for the type MockRepository
-func (_mock *MockRepository) GetOrderPayment(ctx context.Context, orderUID string) (*order.Payment, error) {
-	ret := _mock.Called(ctx, orderUID)
+// Save provides a mock function for the type MockRepository
+func (_mock *MockRepository) Save(ctx context.Context, order1 *order.Order) error {
+	ret := _mock.Called(ctx, order1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrderPayment")
+		panic("no return value specified for Save")
 	}
 
-	var r0 *order.Payment
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*order.Payment, error)); ok {
-		return returnFunc(ctx, orderUID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *order.Payment); ok {
-		r0 = returnFunc(ctx, orderUID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*order.Payment)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, orderUID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *order.Order) error); ok {
+		r0 = returnFunc(ctx, order1)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// MockRepository_GetOrderPayment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderPayment'
-type MockRepository_GetOrderPayment_Call struct {
+// MockRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
+type MockRepository_Save_Call struct {
 	*mock.Call
 }
 
-// GetOrderPayment is a helper method to define mock.On call
+// Save is a helper method to define mock.On call
 //   - ctx context.Context
-//   - orderUID string
-func (_e *MockRepository_Expecter) GetOrderPayment(ctx interface{}, orderUID interface{}) *MockRepository_GetOrderPayment_Call {
-	return &MockRepository_GetOrderPayment_Call{Call: _e.mock.On("GetOrderPayment", ctx, orderUID)}
+//   - order1 *order.Order
+func (_e *MockRepository_Expecter) Save(ctx interface{}, order1 interface{}) *MockRepository_Save_Call {
+	return &MockRepository_Save_Call{Call: _e.mock.On("Save", ctx, order1)}
 }
 
-func (_c *MockRepository_GetOrderPayment_Call) Run(run func(ctx context.Context, orderUID string)) *MockRepository_GetOrderPayment_Call {
+func (_c *MockRepository_Save_Call) Run(run func(ctx context.Context, order1 *order.Order)) *MockRepository_Save_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *order.Order
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*order.Order)
 		}
 		run(
 			arg0,
@@ -366,65 +414,54 @@ func (_c *MockRepository_GetOrderPayment_Call) Run(run func(ctx context.Context,
 	return _c
 }
 
-func (_c *MockRepository_GetOrderPayment_Call) Return(payment *order.Payment, err error) *MockRepository_GetOrderPayment_Call {
-	_c.Call.Return(payment, err)
+func (_c *MockRepository_Save_Call) Return(err error) *MockRepository_Save_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockRepository_GetOrderPayment_Call) RunAndReturn(run func(ctx context.Context, orderUID string) (*order.Payment, error)) *MockRepository_GetOrderPayment_Call {
+func (_c *MockRepository_Save_Call) RunAndReturn(run func(ctx context.Context, order1 *order.Order) error) *MockRepository_Save_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetOrders provides a mock function for the type MockRepository
-func (_mock *MockRepository) GetOrders(ctx context.Context, limit int32) ([]order.Order, error) {
-	ret := _mock.Called(ctx, limit)
+// SaveBatch provides a mock function for the type MockRepository
+func (_mock *MockRepository) SaveBatch(ctx context.Context, orders []*order.Order) error {
+	ret := _mock.Called(ctx, orders)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrders")
+		panic("no return value specified for SaveBatch")
 	}
 
-	var r0 []order.Order
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int32) ([]order.Order, error)); ok {
-		return returnFunc(ctx, limit)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int32) []order.Order); ok {
-		r0 = returnFunc(ctx, limit)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]order.Order)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, int32) error); ok {
-		r1 = returnFunc(ctx, limit)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []*order.Order) error); ok {
+		r0 = returnFunc(ctx, orders)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// MockRepository_GetOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrders'
-type MockRepository_GetOrders_Call struct {
+// MockRepository_SaveBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveBatch'
+type MockRepository_SaveBatch_Call struct {
 	*mock.Call
 }
 
-// GetOrders is a helper method to define mock.On call
+// SaveBatch is a helper method to define mock.On call
 //   - ctx context.Context
-//   - limit int32
-func (_e *MockRepository_Expecter) GetOrders(ctx interface{}, limit interface{}) *MockRepository_GetOrders_Call {
-	return &MockRepository_GetOrders_Call{Call: _e.mock.On("GetOrders", ctx, limit)}
+//   - orders []*order.Order
+func (_e *MockRepository_Expecter) SaveBatch(ctx interface{}, orders interface{}) *MockRepository_SaveBatch_Call {
+	return &MockRepository_SaveBatch_Call{Call: _e.mock.On("SaveBatch", ctx, orders)}
 }
 
-func (_c *MockRepository_GetOrders_Call) Run(run func(ctx context.Context, limit int32)) *MockRepository_GetOrders_Call {
+func (_c *MockRepository_SaveBatch_Call) Run(run func(ctx context.Context, orders []*order.Order)) *MockRepository_SaveBatch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 int32
+		var arg1 []*order.Order
 		if args[1] != nil {
-			arg1 = args[1].(int32)
+			arg1 = args[1].([]*order.Order)
 		}
 		run(
 			arg0,
@@ -434,54 +471,54 @@ func (_c *MockRepository_GetOrders_Call) Run(run func(ctx context.Context, limit
 	return _c
 }
 
-func (_c *MockRepository_GetOrders_Call) Return(orders []order.Order, err error) *MockRepository_GetOrders_Call {
-	_c.Call.Return(orders, err)
+func (_c *MockRepository_SaveBatch_Call) Return(err error) *MockRepository_SaveBatch_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockRepository_GetOrders_Call) RunAndReturn(run func(ctx context.Context, limit int32) ([]order.Order, error)) *MockRepository_GetOrders_Call {
+func (_c *MockRepository_SaveBatch_Call) RunAndReturn(run func(ctx context.Context, orders []*order.Order) error) *MockRepository_SaveBatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Save provides a mock function for the type MockRepository
-func (_mock *MockRepository) Save(ctx context.Context, order1 *order.Order) error {
-	ret := _mock.Called(ctx, order1)
+// Update provides a mock function for the type MockRepository
+func (_mock *MockRepository) Update(ctx context.Context, update *order.Update) error {
+	ret := _mock.Called(ctx, update)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Save")
+		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *order.Order) error); ok {
-		r0 = returnFunc(ctx, order1)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *order.Update) error); ok {
+		r0 = returnFunc(ctx, update)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockRepository_Save_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Save'
-type MockRepository_Save_Call struct {
+// MockRepository_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockRepository_Update_Call struct {
 	*mock.Call
 }
 
-// Save is a helper method to define mock.On call
+// Update is a helper method to define mock.On call
 //   - ctx context.Context
-//   - order1 *order.Order
-func (_e *MockRepository_Expecter) Save(ctx interface{}, order1 interface{}) *MockRepository_Save_Call {
-	return &MockRepository_Save_Call{Call: _e.mock.On("Save", ctx, order1)}
+//   - update *order.Update
+func (_e *MockRepository_Expecter) Update(ctx interface{}, update interface{}) *MockRepository_Update_Call {
+	return &MockRepository_Update_Call{Call: _e.mock.On("Update", ctx, update)}
 }
 
-func (_c *MockRepository_Save_Call) Run(run func(ctx context.Context, order1 *order.Order)) *MockRepository_Save_Call {
+func (_c *MockRepository_Update_Call) Run(run func(ctx context.Context, update *order.Update)) *MockRepository_Update_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *order.Order
+		var arg1 *order.Update
 		if args[1] != nil {
-			arg1 = args[1].(*order.Order)
+			arg1 = args[1].(*order.Update)
 		}
 		run(
 			arg0,
@@ -491,12 +528,12 @@ func (_c *MockRepository_Save_Call) Run(run func(ctx context.Context, order1 *or
 	return _c
 }
 
-func (_c *MockRepository_Save_Call) Return(err error) *MockRepository_Save_Call {
+func (_c *MockRepository_Update_Call) Return(err error) *MockRepository_Update_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockRepository_Save_Call) RunAndReturn(run func(ctx context.Context, order1 *order.Order) error) *MockRepository_Save_Call {
+func (_c *MockRepository_Update_Call) RunAndReturn(run func(ctx context.Context, update *order.Update) error) *MockRepository_Update_Call {
 	_c.Call.Return(run)
 	return _c
 }
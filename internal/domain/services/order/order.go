@@ -2,51 +2,122 @@ package order
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	serviceErrors "wb-L0-task/internal/domain/errors"
 	model "wb-L0-task/internal/domain/order"
 	"wb-L0-task/internal/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
 )
 
-const initCacheSize = 10
+// staleRefreshTimeout limits background refresh of stale order
+const staleRefreshTimeout = 5 * time.Second
 
 type Repository interface {
 	GetById(ctx context.Context, orderUID string) (*model.Order, error)
 	Exists(ctx context.Context, orderUID string) (bool, error)
 	Save(ctx context.Context, order *model.Order) error
-	GetOrders(ctx context.Context, limit int32) ([]model.Order, error)
-	GetOrderDelivery(ctx context.Context, orderUID string) (*model.Delivery, error)
-	GetOrderPayment(ctx context.Context, orderUID string) (*model.Payment, error)
-	GetOrderItems(ctx context.Context, orderUID string) ([]model.Item, error)
+	SaveBatch(ctx context.Context, orders []*model.Order) error
+	Replace(ctx context.Context, order *model.Order) error
+	Update(ctx context.Context, update *model.Update) error
+	Delete(ctx context.Context, orderUID string) error
+	GetLatestOrders(ctx context.Context, createdAfter time.Time, after *model.Order, limit int32) ([]model.Order, error)
 }
 
 type Cache[T any] interface {
 	Set(k string, v T, expiration time.Duration)
 	Get(k string) (T, bool)
+	GetStale(k string) (T, bool)
+	Delete(k string)
+	Flush()
 }
 
 type Order struct {
-	storage Repository
-	cache   Cache[model.Order]
+	storage  Repository
+	cache    Cache[model.Order]
+	negative Cache[struct{}]
+	loads    singleflight.Group
+	// invalidations counts invalidations, so load which started before one doesn't cache unknown order uid
+	negativeMu    sync.Mutex
+	invalidations uint64
 }
 
-func New(cache Cache[model.Order], storage Repository) *Order {
+// New creates order service.
+// If negative cache is not nil, unknown order uids are remembered there until its expiration.
+func New(cache Cache[model.Order], negative Cache[struct{}], storage Repository) *Order {
 	return &Order{
-		storage: storage,
-		cache:   cache,
+		storage:  storage,
+		cache:    cache,
+		negative: negative,
 	}
 }
 
-func (o *Order) GetOrderById(ctx context.Context, orderId string) (*model.Order, error) {
+// GetOrderById returns order from cache or storage.
+// Expired order within cache grace time is returned immediately with stale flag
+// and refreshed in background. If refresh fails, stale order keeps being served.
+func (o *Order) GetOrderById(ctx context.Context, orderId string) (*model.Order, bool, error) {
 	// Cache search
 	if order, exists := o.cache.Get(orderId); exists {
 		logger.Debug("Cache hit, give order from cache", "order_id", orderId)
-		return &order, nil
+		return &order, false, nil
+	}
+
+	if order, exists := o.cache.GetStale(orderId); exists {
+		logger.Debug("Stale cache hit, give order from cache and refresh it", "order_id", orderId)
+		o.revalidate(orderId)
+		return &order, true, nil
 	}
 
-	// Get it from DB
+	if o.negative != nil {
+		if _, notFound := o.negative.Get(orderId); notFound {
+			logger.Debug("Negative cache hit, order is unknown", "order_id", orderId)
+			return nil, false, serviceErrors.ErrNotFound.ForEntity("order")
+		}
+	}
+
+	// Get it from DB. Concurrent misses for the same order share one load.
+	// Load is detached from request context, so cancellation of one waiter does not fail others.
 	logger.Debug("Cache miss, give order from DB", "order_id", orderId)
+	loading := o.loads.DoChan(orderId, func() (any, error) {
+		return o.loadOrder(context.WithoutCancel(ctx), orderId)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case res := <-loading:
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+		// Every waiter gets its own copy of shared result
+		order := *res.Val.(*model.Order) //nolint:forcetypeassert
+		return &order, false, nil
+	}
+}
+
+// revalidate reloads stale order in background. Concurrent refreshes of the same order are shared.
+func (o *Order) revalidate(orderId string) {
+	o.loads.DoChan(orderId, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), staleRefreshTimeout)
+		defer cancel()
+
+		order, err := o.loadOrder(ctx, orderId)
+		if err != nil {
+			if errors.Is(err, serviceErrors.ErrNotFound) {
+				o.cache.Delete(orderId)
+			} else {
+				logger.Warn("Failed to refresh stale order, keep serving it", "order_id", orderId, "err", err)
+			}
+		}
+		return order, err
+	})
+}
+
+func (o *Order) loadOrder(ctx context.Context, orderId string) (*model.Order, error) {
+	generation := o.invalidation()
 	exists, err := o.storage.Exists(ctx, orderId)
 	if err != nil {
 		return nil, err
@@ -61,40 +132,73 @@ func (o *Order) GetOrderById(ctx context.Context, orderId string) (*model.Order,
 		o.cache.Set(orderId, *res, 0)
 		return res, nil
 	} else {
+		o.rememberUnknown(orderId, generation)
 		return nil, serviceErrors.ErrNotFound.ForEntity("order")
 	}
 }
 
-func (o *Order) InitCache(ctx context.Context) error {
-	logger.Info("Initializing orders cache", "cache_size", initCacheSize)
-	orders, err := o.storage.GetOrders(ctx, initCacheSize)
-	for i := range orders {
-		delivery, err := o.storage.GetOrderDelivery(ctx, orders[i].UID)
-		if err != nil {
-			logger.Error("Failed to get order delivery", "order_uid", orders[i].UID, "error", err)
-			return err
-		}
-		orders[i].Delivery = *delivery
+// invalidation returns generation of invalidations.
+func (o *Order) invalidation() uint64 {
+	o.negativeMu.Lock()
+	defer o.negativeMu.Unlock()
+	return o.invalidations
+}
 
-		payment, err := o.storage.GetOrderPayment(ctx, orders[i].UID)
-		if err != nil {
-			logger.Error("Failed to get order payment", "order_uid", orders[i].UID, "error", err)
-			return err
-		}
-		orders[i].Payment = *payment
+// rememberUnknown caches unknown order uid, unless orders were invalidated since generation.
+// Order could be created after it was looked up, then negative entry would hide it until expiration.
+func (o *Order) rememberUnknown(orderId string, generation uint64) {
+	if o.negative == nil {
+		return
+	}
+	o.negativeMu.Lock()
+	defer o.negativeMu.Unlock()
+	if o.invalidations != generation {
+		logger.Debug("Orders are invalidated while loading, unknown order uid is not cached", "order_id", orderId)
+		return
+	}
+	o.negative.Set(orderId, struct{}{}, 0)
+}
 
-		items, err := o.storage.GetOrderItems(ctx, orders[i].UID)
-		if err != nil {
-			logger.Error("Failed to get order items", "order_uid", orders[i].UID, "error", err)
-			return err
-		}
-		orders[i].Items = items
+// forgetUnknown clears negative cache entry and starts new generation of invalidations.
+func (o *Order) forgetUnknown(fn func(negative Cache[struct{}])) {
+	o.negativeMu.Lock()
+	defer o.negativeMu.Unlock()
+	o.invalidations++
+	if o.negative != nil {
+		fn(o.negative)
 	}
-	if err != nil {
-		return err
+}
+
+// Invalidate refreshes cached order after it was changed by any replica.
+// Orders which are not cached are left untouched, orders which can't be loaded are evicted.
+// Negative cache entry is always cleared, because the order could be just created.
+func (o *Order) Invalidate(ctx context.Context, orderId string) {
+	o.forgetUnknown(func(negative Cache[struct{}]) { negative.Delete(orderId) })
+	if _, cached := o.cache.Get(orderId); !cached {
+		if _, stale := o.cache.GetStale(orderId); !stale {
+			return
+		}
 	}
-	for _, order := range orders {
-		o.cache.Set(order.UID, order, 30*time.Second)
+
+	logger.Debug("Refresh changed order in cache", "order_id", orderId)
+	if _, err := o.loadOrder(ctx, orderId); err != nil {
+		if !errors.Is(err, serviceErrors.ErrNotFound) {
+			logger.Warn("Failed to refresh changed order, evict it", "order_id", orderId, "err", err)
+		}
+		o.cache.Delete(orderId)
 	}
-	return nil
+}
+
+// InvalidateUnknown clears negative cache entry of order which is just created or changed,
+// without refreshing cached order.
+func (o *Order) InvalidateUnknown(_ context.Context, orderId string) {
+	o.forgetUnknown(func(negative Cache[struct{}]) { negative.Delete(orderId) })
+}
+
+// InvalidateAll drops all cached orders and unknown order uids.
+// It is called when changes of orders could be missed, e.g. after listener reconnect.
+func (o *Order) InvalidateAll(context.Context) {
+	logger.Info("Drop all cached orders")
+	o.forgetUnknown(func(negative Cache[struct{}]) { negative.Flush() })
+	o.cache.Flush()
 }
@@ -3,6 +3,8 @@ package order
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,7 +20,7 @@ func TestOrder_GetOrderById_CacheHit(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
 	expectedOrder := &model.Order{
 		UID:         "test123",
@@ -27,9 +29,10 @@ func TestOrder_GetOrderById_CacheHit(t *testing.T) {
 	}
 	mockCache.On("Get", "test123").Return(*expectedOrder, true).Once()
 
-	result, err := orderService.GetOrderById(context.Background(), "test123")
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
 
 	require.NoError(t, err)
+	assert.False(t, stale)
 	assert.Equal(t, expectedOrder, result)
 	mockCache.AssertExpectations(t)
 	mockRepo.AssertNotCalled(t, "Exists")
@@ -40,9 +43,10 @@ func TestOrder_GetOrderById_CacheMiss_DBFound(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
 	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
 
 	mockRepo.On("Exists", mock.Anything, "test123").Return(true, nil).Once()
 
@@ -55,9 +59,10 @@ func TestOrder_GetOrderById_CacheMiss_DBFound(t *testing.T) {
 
 	mockCache.On("Set", "test123", *expectedOrder, time.Duration(0)).Once()
 
-	result, err := orderService.GetOrderById(context.Background(), "test123")
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
 
 	require.NoError(t, err)
+	assert.False(t, stale)
 	assert.Equal(t, expectedOrder, result)
 	mockCache.AssertExpectations(t)
 	mockRepo.AssertExpectations(t)
@@ -67,15 +72,17 @@ func TestOrder_GetOrderById_CacheMiss_DBNotFound(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
 	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
 
 	mockRepo.On("Exists", mock.Anything, "test123").Return(false, nil).Once()
 
-	result, err := orderService.GetOrderById(context.Background(), "test123")
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
 
 	require.Error(t, err)
+	assert.False(t, stale)
 	assert.Nil(t, result)
 	assert.True(t, errors.Is(err, errors_pkg.ErrNotFound))
 	mockCache.AssertExpectations(t)
@@ -87,15 +94,17 @@ func TestOrder_GetOrderById_DBError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
 	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
 
 	mockRepo.On("Exists", mock.Anything, "test123").Return(false, assert.AnError).Once()
 
-	result, err := orderService.GetOrderById(context.Background(), "test123")
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
 
 	require.Error(t, err)
+	assert.False(t, stale)
 	assert.Nil(t, result)
 	assert.Equal(t, assert.AnError, err)
 	mockCache.AssertExpectations(t)
@@ -107,16 +116,18 @@ func TestOrder_GetOrderById_DBGetError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
 	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
 
 	mockRepo.On("Exists", mock.Anything, "test123").Return(true, nil).Once()
 	mockRepo.On("GetById", mock.Anything, "test123").Return(nil, assert.AnError).Once()
 
-	result, err := orderService.GetOrderById(context.Background(), "test123")
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
 
 	require.Error(t, err)
+	assert.False(t, stale)
 	assert.Nil(t, result)
 	assert.Equal(t, assert.AnError, err)
 	mockCache.AssertExpectations(t)
@@ -124,132 +135,416 @@ func TestOrder_GetOrderById_DBGetError(t *testing.T) {
 	mockCache.AssertNotCalled(t, "Set")
 }
 
-func TestOrder_InitCache_Success(t *testing.T) {
+func TestOrder_GetOrderById_ConcurrentMissesShareLoad(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
+
+	const waiters = 5
+	release := make(chan struct{})
+	var misses atomic.Int32
+	expectedOrder := &model.Order{UID: "test123", TrackNumber: "TRACK123"}
+
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Times(waiters)
+	mockCache.On("GetStale", "test123").
+		Run(func(mock.Arguments) { misses.Add(1) }).
+		Return(model.Order{}, false).
+		Times(waiters)
+	mockRepo.On("Exists", mock.Anything, "test123").
+		Run(func(mock.Arguments) { <-release }).
+		Return(true, nil).
+		Once()
+	mockRepo.On("GetById", mock.Anything, "test123").Return(expectedOrder, nil).Once()
+	mockCache.On("Set", "test123", *expectedOrder, time.Duration(0)).Once()
 
-	orders := []model.Order{
-		{UID: "order1", TrackNumber: "TRACK1"},
-		{UID: "order2", TrackNumber: "TRACK2"},
+	var wg sync.WaitGroup
+	results := make(chan *model.Order, waiters)
+	wg.Add(waiters)
+	for range waiters {
+		go func() {
+			defer wg.Done()
+			result, stale, err := orderService.GetOrderById(context.Background(), "test123")
+			assert.NoError(t, err)
+			assert.False(t, stale)
+			results <- result
+		}()
 	}
 
-	mockRepo.On("GetOrders", mock.Anything, int32(10)).Return(orders, nil).Once()
+	// Wait until every waiter missed the cache before releasing the load
+	require.Eventually(t, func() bool {
+		return misses.Load() == waiters
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
 
-	delivery1 := &model.Delivery{Name: "John Doe"}
-	delivery2 := &model.Delivery{Name: "Jane Smith"}
-	mockRepo.On("GetOrderDelivery", mock.Anything, "order1").Return(delivery1, nil).Once()
-	mockRepo.On("GetOrderDelivery", mock.Anything, "order2").Return(delivery2, nil).Once()
+	for result := range results {
+		assert.Equal(t, expectedOrder, result)
+	}
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
 
-	payment1 := &model.Payment{TransactionID: "trans1"}
-	payment2 := &model.Payment{TransactionID: "trans2"}
-	mockRepo.On("GetOrderPayment", mock.Anything, "order1").Return(payment1, nil).Once()
-	mockRepo.On("GetOrderPayment", mock.Anything, "order2").Return(payment2, nil).Once()
+func TestOrder_GetOrderById_SharedLoadError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
 
-	items1 := []model.Item{{ChartID: 1}}
-	items2 := []model.Item{{ChartID: 2}}
-	mockRepo.On("GetOrderItems", mock.Anything, "order1").Return(items1, nil).Once()
-	mockRepo.On("GetOrderItems", mock.Anything, "order2").Return(items2, nil).Once()
+	orderService := New(mockCache, nil, mockRepo)
+
+	release := make(chan struct{})
+	var misses atomic.Int32
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Twice()
+	mockCache.On("GetStale", "test123").
+		Run(func(mock.Arguments) { misses.Add(1) }).
+		Return(model.Order{}, false).
+		Twice()
+	mockRepo.On("Exists", mock.Anything, "test123").
+		Run(func(mock.Arguments) { <-release }).
+		Return(false, assert.AnError).
+		Once()
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, _, err := orderService.GetOrderById(context.Background(), "test123")
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return misses.Load() == 2
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	assert.Equal(t, assert.AnError, <-errs)
+	assert.Equal(t, assert.AnError, <-errs)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrder_GetOrderById_WaiterContextCanceled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
 
-	expectedOrder1 := orders[0]
-	expectedOrder1.Delivery = *delivery1
-	expectedOrder1.Payment = *payment1
-	expectedOrder1.Items = items1
+	orderService := New(mockCache, nil, mockRepo)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	expectedOrder := &model.Order{UID: "test123"}
+
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Twice()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Twice()
+	mockRepo.On("Exists", mock.Anything, "test123").
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(true, nil).
+		Once()
+	mockRepo.On("GetById", mock.Anything, "test123").Return(expectedOrder, nil).Once()
+	mockCache.On("Set", "test123", *expectedOrder, time.Duration(0)).Once()
 
-	expectedOrder2 := orders[1]
-	expectedOrder2.Delivery = *delivery2
-	expectedOrder2.Payment = *payment2
-	expectedOrder2.Items = items2
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := orderService.GetOrderById(context.Background(), "test123")
+		done <- err
+	}()
+	<-started
 
-	mockCache.On("Set", "order1", expectedOrder1, 30*time.Second).Once()
-	mockCache.On("Set", "order2", expectedOrder2, 30*time.Second).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, stale, err := orderService.GetOrderById(ctx, "test123")
 
-	err := orderService.InitCache(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, stale)
+	assert.Nil(t, result)
 
-	require.NoError(t, err)
+	// Canceled waiter does not affect the load itself
+	close(release)
+	require.NoError(t, <-done)
 	mockRepo.AssertExpectations(t)
 	mockCache.AssertExpectations(t)
 }
 
-func TestOrder_InitCache_GetOrdersError(t *testing.T) {
+func TestOrder_Invalidate_NotCached(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
-	mockRepo.On("GetOrders", mock.Anything, int32(10)).Return(nil, assert.AnError).Once()
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
 
-	err := orderService.InitCache(context.Background())
+	orderService.Invalidate(context.Background(), "test123")
 
-	require.Error(t, err)
-	assert.Equal(t, assert.AnError, err)
-	mockRepo.AssertExpectations(t)
-	mockCache.AssertNotCalled(t, "Set")
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetById")
+	mockCache.AssertNotCalled(t, "Delete")
 }
 
-func TestOrder_InitCache_DeliveryError(t *testing.T) {
+func TestOrder_Invalidate_Refresh(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, nil, mockRepo)
 
-	orders := []model.Order{
-		{UID: "order1", TrackNumber: "TRACK1"},
+	updatedOrder := &model.Order{UID: "test123", TrackNumber: "TRACK2"}
+	mockCache.On("Get", "test123").Return(model.Order{UID: "test123", TrackNumber: "TRACK1"}, true).Once()
+	mockRepo.On("Exists", mock.Anything, "test123").Return(true, nil).Once()
+	mockRepo.On("GetById", mock.Anything, "test123").Return(updatedOrder, nil).Once()
+	mockCache.On("Set", "test123", *updatedOrder, time.Duration(0)).Once()
+
+	orderService.Invalidate(context.Background(), "test123")
+
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Delete")
+}
+
+func TestOrder_Invalidate_EvictOnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		exists bool
+		err    error
+	}{
+		{"order removed", false, nil},
+		{"storage error", false, assert.AnError},
 	}
 
-	mockRepo.On("GetOrders", mock.Anything, int32(10)).Return(orders, nil).Once()
-	mockRepo.On("GetOrderDelivery", mock.Anything, "order1").Return(nil, assert.AnError).Once()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[model.Order])
 
-	err := orderService.InitCache(context.Background())
+			orderService := New(mockCache, nil, mockRepo)
 
-	require.Error(t, err)
-	assert.Equal(t, assert.AnError, err)
-	mockRepo.AssertExpectations(t)
-	mockCache.AssertNotCalled(t, "Set")
+			mockCache.On("Get", "test123").Return(model.Order{UID: "test123"}, true).Once()
+			mockRepo.On("Exists", mock.Anything, "test123").Return(tc.exists, tc.err).Once()
+			mockCache.On("Delete", "test123").Once()
+
+			orderService.Invalidate(context.Background(), "test123")
+
+			mockCache.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertNotCalled(t, "Set")
+		})
+	}
 }
 
-func TestOrder_InitCache_PaymentError(t *testing.T) {
+func TestOrder_GetOrderById_NegativeCacheHit(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, mockNegative, mockRepo)
 
-	orders := []model.Order{
-		{UID: "order1", TrackNumber: "TRACK1"},
-	}
+	mockCache.On("Get", "unknown").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "unknown").Return(model.Order{}, false).Once()
+	mockNegative.On("Get", "unknown").Return(struct{}{}, true).Once()
 
-	mockRepo.On("GetOrders", mock.Anything, int32(10)).Return(orders, nil).Once()
-	mockRepo.On("GetOrderDelivery", mock.Anything, "order1").Return(&model.Delivery{}, nil).Once()
-	mockRepo.On("GetOrderPayment", mock.Anything, "order1").Return(nil, assert.AnError).Once()
+	result, stale, err := orderService.GetOrderById(context.Background(), "unknown")
 
-	err := orderService.InitCache(context.Background())
+	require.ErrorIs(t, err, errors_pkg.ErrNotFound)
+	assert.False(t, stale)
+	assert.Equal(t, "order not found", err.Error())
+	assert.Nil(t, result)
+	mockCache.AssertExpectations(t)
+	mockNegative.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Exists")
+}
 
-	require.Error(t, err)
-	assert.Equal(t, assert.AnError, err)
+func TestOrder_GetOrderById_NegativeCacheStore(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
+
+	orderService := New(mockCache, mockNegative, mockRepo)
+
+	mockCache.On("Get", "unknown").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "unknown").Return(model.Order{}, false).Once()
+	mockNegative.On("Get", "unknown").Return(struct{}{}, false).Once()
+	mockRepo.On("Exists", mock.Anything, "unknown").Return(false, nil).Once()
+	mockNegative.On("Set", "unknown", struct{}{}, time.Duration(0)).Once()
+
+	result, stale, err := orderService.GetOrderById(context.Background(), "unknown")
+
+	require.ErrorIs(t, err, errors_pkg.ErrNotFound)
+	assert.False(t, stale)
+	assert.Nil(t, result)
+	mockCache.AssertExpectations(t)
+	mockNegative.AssertExpectations(t)
 	mockRepo.AssertExpectations(t)
-	mockCache.AssertNotCalled(t, "Set")
 }
 
-func TestOrder_InitCache_ItemsError(t *testing.T) {
+func TestOrder_GetOrderById_NegativeCacheSkipsErrors(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
 
-	orderService := New(mockCache, mockRepo)
+	orderService := New(mockCache, mockNegative, mockRepo)
 
-	orders := []model.Order{
-		{UID: "order1", TrackNumber: "TRACK1"},
-	}
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
+	mockNegative.On("Get", "test123").Return(struct{}{}, false).Once()
+	mockRepo.On("Exists", mock.Anything, "test123").Return(false, assert.AnError).Once()
 
-	mockRepo.On("GetOrders", mock.Anything, int32(10)).Return(orders, nil).Once()
-	mockRepo.On("GetOrderDelivery", mock.Anything, "order1").Return(&model.Delivery{}, nil).Once()
-	mockRepo.On("GetOrderPayment", mock.Anything, "order1").Return(&model.Payment{}, nil).Once()
-	mockRepo.On("GetOrderItems", mock.Anything, "order1").Return(nil, assert.AnError).Once()
+	_, _, err := orderService.GetOrderById(context.Background(), "test123")
 
-	err := orderService.InitCache(context.Background())
+	require.ErrorIs(t, err, assert.AnError)
+	mockNegative.AssertNotCalled(t, "Set")
+}
 
-	require.Error(t, err)
-	assert.Equal(t, assert.AnError, err)
+func TestOrder_Invalidate_ClearsNegativeCache(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
+
+	orderService := New(mockCache, mockNegative, mockRepo)
+
+	mockNegative.On("Delete", "test123").Once()
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false).Once()
+
+	orderService.Invalidate(context.Background(), "test123")
+
+	mockNegative.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrder_GetOrderById_InvalidatedWhileLoading(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
+
+	orderService := New(mockCache, mockNegative, mockRepo)
+
+	mockCache.On("Get", "test123").Return(model.Order{}, false)
+	mockCache.On("GetStale", "test123").Return(model.Order{}, false)
+	mockNegative.On("Get", "test123").Return(struct{}{}, false).Once()
+	mockNegative.On("Delete", "test123").Once()
+	// Order is created by other replica after it was looked up
+	mockRepo.On("Exists", mock.Anything, "test123").Return(false, nil).Once().Run(func(mock.Arguments) {
+		orderService.Invalidate(context.Background(), "test123")
+	})
+
+	_, _, err := orderService.GetOrderById(context.Background(), "test123")
+
+	require.ErrorIs(t, err, errors_pkg.ErrNotFound)
+	mockNegative.AssertExpectations(t)
+	mockNegative.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrder_InvalidateUnknown(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
+
+	orderService := New(mockCache, mockNegative, mockRepo)
+
+	mockNegative.On("Delete", "test123").Once()
+
+	orderService.InvalidateUnknown(context.Background(), "test123")
+
+	mockNegative.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Get", mock.Anything)
+	mockRepo.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+}
+
+func TestOrder_InvalidateAll(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+	mockNegative := new(MockCache[struct{}])
+
+	orderService := New(mockCache, mockNegative, mockRepo)
+
+	mockNegative.On("Flush").Once()
+	mockCache.On("Flush").Once()
+
+	orderService.InvalidateAll(context.Background())
+
+	mockNegative.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetById")
+}
+
+func TestOrder_GetOrderById_StaleHit(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	staleOrder := model.Order{UID: "test123", TrackNumber: "TRACK1"}
+	freshOrder := &model.Order{UID: "test123", TrackNumber: "TRACK2"}
+	refreshed := make(chan struct{})
+
+	mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+	mockCache.On("GetStale", "test123").Return(staleOrder, true).Once()
+	mockRepo.On("Exists", mock.Anything, "test123").Return(true, nil).Once()
+	mockRepo.On("GetById", mock.Anything, "test123").Return(freshOrder, nil).Once()
+	mockCache.On("Set", "test123", *freshOrder, time.Duration(0)).
+		Run(func(mock.Arguments) { close(refreshed) }).
+		Once()
+
+	result, stale, err := orderService.GetOrderById(context.Background(), "test123")
+
+	require.NoError(t, err)
+	assert.True(t, stale)
+	assert.Equal(t, &staleOrder, result)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale order was not refreshed")
+	}
+	mockCache.AssertExpectations(t)
 	mockRepo.AssertExpectations(t)
-	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestOrder_GetOrderById_StaleRefreshFailure(t *testing.T) {
+	testCases := []struct {
+		name    string
+		exists  bool
+		err     error
+		evicted bool
+	}{
+		{"storage error keeps stale order", false, assert.AnError, false},
+		{"removed order is evicted", false, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[model.Order])
+
+			orderService := New(mockCache, nil, mockRepo)
+
+			staleOrder := model.Order{UID: "test123"}
+			refreshed := make(chan struct{})
+
+			mockCache.On("Get", "test123").Return(model.Order{}, false).Once()
+			mockCache.On("GetStale", "test123").Return(staleOrder, true).Once()
+			mockRepo.On("Exists", mock.Anything, "test123").
+				Run(func(mock.Arguments) { close(refreshed) }).
+				Return(tc.exists, tc.err).
+				Once()
+			if tc.evicted {
+				mockCache.On("Delete", "test123").Once()
+			}
+
+			result, stale, err := orderService.GetOrderById(context.Background(), "test123")
+
+			require.NoError(t, err)
+			assert.True(t, stale)
+			assert.Equal(t, &staleOrder, result)
+
+			<-refreshed
+			// Wait for shared refresh to finish
+			_, _, _ = orderService.loads.Do("test123", func() (any, error) { return nil, nil })
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+			mockCache.AssertNotCalled(t, "Set")
+			if !tc.evicted {
+				mockCache.AssertNotCalled(t, "Delete")
+			}
+		})
+	}
 }
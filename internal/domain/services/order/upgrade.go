@@ -0,0 +1,56 @@
+package order
+
+import (
+	"encoding/json"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	models "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/logger"
+)
+
+// CurrentSchemaVersion is schema version of order payload which matches models.Order.
+const CurrentSchemaVersion = 2
+
+// upgraders turn JSON order payload of every supported schema version into current order.
+// Upgrader of a new schema version is added here when producers change order format.
+//
+//nolint:gochecknoglobals
+var upgraders = map[int]func(payload []byte) (*models.Order, error){
+	1: upgradeV1,
+	2: upgradeV2,
+}
+
+// upgradeOrder decodes payload of schema version into current order.
+// Unknown schema version is reported with ErrBrokenEntity.
+func upgradeOrder(schemaVersion int, payload []byte) (*models.Order, error) {
+	upgrade, ok := upgraders[schemaVersion]
+	if !ok {
+		logger.Error("Unknown schema version of order", "schema_version", schemaVersion)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("schema version")
+	}
+	return upgrade(payload)
+}
+
+// upgradeV1 decodes order of the first schema, which had no version of order.
+// Every order of this schema is the initial version, whatever payload contains.
+func upgradeV1(payload []byte) (*models.Order, error) {
+	order, err := upgradeV2(payload)
+	if err != nil {
+		return nil, err
+	}
+	order.Version = models.InitialVersion
+	return order, nil
+}
+
+// upgradeV2 decodes order of the current schema.
+func upgradeV2(payload []byte) (*models.Order, error) {
+	if len(payload) == 0 || string(payload) == "null" {
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	var order models.Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		logger.Error("Failed to unmarshal order payload", "error", err)
+		return nil, serviceErrors.ErrBrokenEntity.ForEntity("order")
+	}
+	return &order, nil
+}
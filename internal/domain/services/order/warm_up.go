@@ -0,0 +1,83 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	model "wb-L0-task/internal/domain/order"
+	"wb-L0-task/internal/pkg/logger"
+)
+
+type WarmUpStrategy string
+
+const (
+	// WarmUpNone leaves cache empty on start
+	WarmUpNone WarmUpStrategy = "none"
+	// WarmUpRecent loads the most recent orders
+	WarmUpRecent WarmUpStrategy = "recent"
+	// WarmUpWindow loads all orders created within time window
+	WarmUpWindow WarmUpStrategy = "window"
+
+	defaultWarmUpBatchSize = 100
+)
+
+var ErrUnknownWarmUpStrategy = errors.New("unknown cache warm-up strategy")
+
+// WarmUp describes which orders are loaded into cache on start.
+type WarmUp struct {
+	Strategy WarmUpStrategy
+	// Size is a number of orders loaded by WarmUpRecent strategy
+	Size int
+	// Window is a maximum age of orders loaded by WarmUpWindow strategy
+	Window time.Duration
+	// BatchSize is a number of orders loaded by one storage call
+	BatchSize int
+	// Expiration of loaded orders. If 0, cache default expiration is used
+	Expiration time.Duration
+}
+
+// InitCache loads orders into cache according to warm-up strategy, newest first.
+func (o *Order) InitCache(ctx context.Context, warmUp WarmUp) error {
+	var createdAfter time.Time
+	limit := math.MaxInt
+	switch warmUp.Strategy {
+	case WarmUpNone, "":
+		logger.Info("Cache warm-up disabled")
+		return nil
+	case WarmUpRecent:
+		limit = warmUp.Size
+	case WarmUpWindow:
+		createdAfter = time.Now().Add(-warmUp.Window)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownWarmUpStrategy, warmUp.Strategy)
+	}
+	batchSize := warmUp.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWarmUpBatchSize
+	}
+
+	logger.Info("Initializing orders cache", "strategy", warmUp.Strategy, "size", warmUp.Size, "window", warmUp.Window)
+	loaded := 0
+	var last *model.Order
+	for loaded < limit {
+		size := min(batchSize, limit-loaded)
+		orders, err := o.storage.GetLatestOrders(ctx, createdAfter, last, int32(size)) //nolint:gosec
+		if err != nil {
+			logger.Error("Failed to load orders for cache", "loaded", loaded, "error", err)
+			return err
+		}
+		for _, order := range orders {
+			o.cache.Set(order.UID, order, warmUp.Expiration)
+		}
+		loaded += len(orders)
+		if len(orders) < size {
+			break
+		}
+		last = &orders[len(orders)-1]
+	}
+	logger.Info("Orders cache initialized", "loaded", loaded)
+	return nil
+}
@@ -0,0 +1,144 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "wb-L0-task/internal/domain/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder_InitCache_Recent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	firstBatch := []model.Order{{UID: "order3"}, {UID: "order2"}}
+	secondBatch := []model.Order{{UID: "order1"}}
+
+	mockRepo.On("GetLatestOrders", mock.Anything, time.Time{}, (*model.Order)(nil), int32(2)).
+		Return(firstBatch, nil).
+		Once()
+	mockRepo.On("GetLatestOrders", mock.Anything, time.Time{}, &firstBatch[1], int32(1)).
+		Return(secondBatch, nil).
+		Once()
+	mockCache.On("Set", "order3", firstBatch[0], 30*time.Second).Once()
+	mockCache.On("Set", "order2", firstBatch[1], 30*time.Second).Once()
+	mockCache.On("Set", "order1", secondBatch[0], 30*time.Second).Once()
+
+	err := orderService.InitCache(context.Background(), WarmUp{
+		Strategy:   WarmUpRecent,
+		Size:       3,
+		BatchSize:  2,
+		Expiration: 30 * time.Second,
+	})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrder_InitCache_Window(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	orders := []model.Order{{UID: "order2"}, {UID: "order1"}}
+	createdAfter := mock.MatchedBy(func(t time.Time) bool {
+		age := time.Since(t)
+		return age >= 2*time.Hour && age < 2*time.Hour+time.Minute
+	})
+
+	mockRepo.On("GetLatestOrders", mock.Anything, createdAfter, (*model.Order)(nil), int32(2)).
+		Return(orders, nil).
+		Once()
+	mockRepo.On("GetLatestOrders", mock.Anything, createdAfter, &orders[1], int32(2)).
+		Return([]model.Order{}, nil).
+		Once()
+	mockCache.On("Set", "order2", orders[0], time.Duration(0)).Once()
+	mockCache.On("Set", "order1", orders[1], time.Duration(0)).Once()
+
+	err := orderService.InitCache(context.Background(), WarmUp{
+		Strategy:  WarmUpWindow,
+		Window:    2 * time.Hour,
+		BatchSize: 2,
+	})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrder_InitCache_DefaultBatchSize(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	mockRepo.On("GetLatestOrders", mock.Anything, time.Time{}, (*model.Order)(nil), int32(defaultWarmUpBatchSize)).
+		Return([]model.Order{}, nil).
+		Once()
+
+	err := orderService.InitCache(context.Background(), WarmUp{Strategy: WarmUpRecent, Size: 1000})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set")
+}
+
+func TestOrder_InitCache_None(t *testing.T) {
+	for _, strategy := range []WarmUpStrategy{WarmUpNone, ""} {
+		t.Run("strategy_"+string(strategy), func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockCache := new(MockCache[model.Order])
+
+			orderService := New(mockCache, nil, mockRepo)
+
+			err := orderService.InitCache(context.Background(), WarmUp{Strategy: strategy, Size: 10})
+
+			require.NoError(t, err)
+			mockRepo.AssertNotCalled(t, "GetLatestOrders")
+			mockCache.AssertNotCalled(t, "Set")
+		})
+	}
+}
+
+func TestOrder_InitCache_UnknownStrategy(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	err := orderService.InitCache(context.Background(), WarmUp{Strategy: "oldest"})
+
+	require.ErrorIs(t, err, ErrUnknownWarmUpStrategy)
+	mockRepo.AssertNotCalled(t, "GetLatestOrders")
+}
+
+func TestOrder_InitCache_StorageError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCache := new(MockCache[model.Order])
+
+	orderService := New(mockCache, nil, mockRepo)
+
+	firstBatch := []model.Order{{UID: "order2"}}
+	mockRepo.On("GetLatestOrders", mock.Anything, time.Time{}, (*model.Order)(nil), int32(1)).
+		Return(firstBatch, nil).
+		Once()
+	mockRepo.On("GetLatestOrders", mock.Anything, time.Time{}, &firstBatch[0], int32(1)).
+		Return(nil, assert.AnError).
+		Once()
+	mockCache.On("Set", "order2", firstBatch[0], time.Duration(0)).Once()
+
+	err := orderService.InitCache(context.Background(), WarmUp{Strategy: WarmUpRecent, Size: 2, BatchSize: 1})
+
+	require.ErrorIs(t, err, assert.AnError)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
@@ -0,0 +1,39 @@
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, b.Delay(0))
+	assert.Equal(t, 200*time.Millisecond, b.Delay(1))
+	assert.Equal(t, 800*time.Millisecond, b.Delay(3))
+	assert.Equal(t, time.Second, b.Delay(4))
+	assert.Equal(t, time.Second, b.Delay(100))
+}
+
+func TestBackoff_Delay_Jitter(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for range 100 {
+		delay := b.Delay(4)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, time.Second)
+	}
+}
+
+func TestWait_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Wait(ctx, time.Hour)
+
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
@@ -9,6 +10,36 @@ const defaultCleanupInterval = 1 * time.Minute
 
 type Config struct {
 	DefaultExpirationTime int16 `mapstructure:"default_exp_time"`
+	// MaxEntries limits number of items in cache. 0 means unlimited.
+	MaxEntries int `mapstructure:"max_entries"`
+	// MaxMemory is an approximate memory budget in bytes. 0 means unlimited.
+	MaxMemory int64 `mapstructure:"max_memory"`
+	// Shards is a number of independently locked segments. Values > 1 enable ShardedCache.
+	// Number of shards doesn't exceed MaxEntries, so every shard can hold an element.
+	Shards int `mapstructure:"shards"`
+	// WriteThrough enables cache population by Kafka consumer after order is saved.
+	WriteThrough bool `mapstructure:"write_through"`
+	// NegativeExpirationTime is a time in seconds to remember unknown keys. 0 disables negative caching.
+	NegativeExpirationTime int16 `mapstructure:"negative_exp_time"`
+	// StaleGraceTime is a time in seconds to keep expired items available through GetStale.
+	StaleGraceTime int16 `mapstructure:"stale_grace_time"`
+	// WarmUp describes which items are loaded into cache on start.
+	WarmUp WarmUpConfig `mapstructure:"warm_up"`
+	// SnapshotPath is a file where cache is saved on shutdown and restored from on start. Empty disables snapshots.
+	SnapshotPath string `mapstructure:"snapshot_path"`
+}
+
+type WarmUpConfig struct {
+	// Strategy is one of: recent, window, none.
+	Strategy string `mapstructure:"strategy"`
+	// Size is a number of the most recent items for "recent" strategy.
+	Size int `mapstructure:"size"`
+	// WindowHours is a maximum age of items for "window" strategy.
+	WindowHours int16 `mapstructure:"window_hours"`
+	// BatchSize is a number of items loaded by one query.
+	BatchSize int `mapstructure:"batch_size"`
+	// ExpirationTime of loaded items in seconds. 0 means default expiration time.
+	ExpirationTime int16 `mapstructure:"exp_time"`
 }
 
 type Item[T any] struct {
@@ -17,71 +48,298 @@ type Item[T any] struct {
 	Expiration   int64
 }
 
+// entry is stored in lru list, so element can be found and evicted by key.
+type entry[T any] struct {
+	key  string
+	item Item[T]
+	size int64
+}
+
+// EvictionReason tells why element was removed from cache.
+type EvictionReason int
+
+const (
+	// EvictionExpired means element was removed by GC after expiration and stale grace time.
+	EvictionExpired EvictionReason = iota
+	// EvictionCapacity means element was removed to fit MaxEntries or MaxMemory.
+	EvictionCapacity
+	// EvictionDeleted means element was removed by Delete or Flush.
+	EvictionDeleted
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionExpired:
+		return "expired"
+	case EvictionCapacity:
+		return "capacity"
+	case EvictionDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictedFunc is called for every element removed from cache.
+type EvictedFunc[T any] func(key string, value T, reason EvictionReason)
+
 type Cache[T any] struct {
 	sync.RWMutex
-	items             map[string]Item[T]
+	items             map[string]*list.Element
+	lru               *list.List
+	memory            int64
+	maxEntries        int
+	maxMemory         int64
+	staleGrace        int64
 	defaultExpiration time.Duration
 	cleanupInterval   time.Duration
+	onEvicted         EvictedFunc[T]
+	stop              chan struct{}
+	closeOnce         sync.Once
+}
+
+// evicted is an element removed under lock. Callback is called for it after lock is released.
+type evicted[T any] struct {
+	key    string
+	value  T
+	reason EvictionReason
 }
 
 func NewCache[T any](config *Config) *Cache[T] {
-	items := make(map[string]Item[T])
+	items := make(map[string]*list.Element)
 
 	cache := &Cache[T]{
 		items:             items,
+		lru:               list.New(),
+		maxEntries:        config.MaxEntries,
+		maxMemory:         config.MaxMemory,
+		staleGrace:        int64(time.Duration(config.StaleGraceTime) * time.Second),
 		defaultExpiration: time.Duration(config.DefaultExpirationTime) * time.Second,
 		cleanupInterval:   defaultCleanupInterval,
+		stop:              make(chan struct{}),
 	}
 
-	cache.cleanupInterval = defaultCleanupInterval
 	go cache.StartGC()
 	return cache
 }
 
 // Set Add element to cache.
 // If expiration == 0 default expiration time will be used.
+// If cache is bounded, least recently used elements are evicted to fit the limits.
 func (c *Cache[T]) Set(k string, v T, expiration time.Duration) {
 	if expiration == 0 {
 		expiration = c.defaultExpiration
 	}
+	var size int64
+	if c.maxMemory > 0 {
+		size = entrySize(k, v)
+	}
 	c.Lock()
-	defer c.Unlock()
 
-	c.items[k] = Item[T]{
+	item := Item[T]{
 		Value:        v,
 		CreationTime: time.Now(),
 		Expiration:   time.Now().Add(expiration).UnixNano(),
 	}
+	if el, ok := c.items[k]; ok {
+		e := el.Value.(*entry[T]) //nolint:forcetypeassert
+		c.memory += size - e.size
+		e.item = item
+		e.size = size
+		c.lru.MoveToFront(el)
+	} else {
+		c.items[k] = c.lru.PushFront(&entry[T]{key: k, item: item, size: size})
+		c.memory += size
+	}
+	removed, onEvicted := c.evictOverflow(), c.onEvicted
+	c.Unlock()
+
+	notify(onEvicted, removed)
 }
 
+// Get returns element from cache. For bounded cache successful lookup marks element as recently used.
 func (c *Cache[T]) Get(k string) (T, bool) { //nolint:ireturn
+	if c.bounded() {
+		return c.getAndTouch(k)
+	}
+	c.RLock()
+	defer c.RUnlock()
+
+	el, ok := c.items[k]
+	if !ok {
+		var zeroValue T
+		return zeroValue, false
+	}
+	return valueOf(el.Value.(*entry[T]).item) //nolint:forcetypeassert
+}
+
+func (c *Cache[T]) getAndTouch(k string) (T, bool) { //nolint:ireturn
+	c.Lock()
+	defer c.Unlock()
+
+	el, ok := c.items[k]
+	if !ok {
+		var zeroValue T
+		return zeroValue, false
+	}
+	value, ok := valueOf(el.Value.(*entry[T]).item) //nolint:forcetypeassert
+	if ok {
+		c.lru.MoveToFront(el)
+	}
+	return value, ok
+}
+
+// GetStale returns element which is expired, but still within stale grace time.
+// Fresh elements are returned by Get only.
+func (c *Cache[T]) GetStale(k string) (T, bool) { //nolint:ireturn
 	c.RLock()
 	defer c.RUnlock()
 
 	var zeroValue T
-	item, ok := c.items[k]
+	el, ok := c.items[k]
 	if !ok {
 		return zeroValue, false
 	}
+	item := el.Value.(*entry[T]).item //nolint:forcetypeassert
+	if !isExpired(item) || c.isRemovable(item) {
+		return zeroValue, false
+	}
+	return item.Value, true
+}
 
-	if item.Expiration > 0 {
-		if time.Now().UnixNano() > item.Expiration {
-			return zeroValue, false
-		}
+func valueOf[T any](item Item[T]) (T, bool) { //nolint:ireturn
+	if isExpired(item) {
+		var zeroValue T
+		return zeroValue, false
 	}
 	return item.Value, true
 }
 
-func (c *Cache[T]) StartGC() {
-	for {
-		<-time.After(c.cleanupInterval)
+// Delete removes element from cache.
+func (c *Cache[T]) Delete(k string) {
+	c.Lock()
+	var removed []evicted[T]
+	if el, ok := c.items[k]; ok {
+		removed = append(removed, c.removeElement(el, EvictionDeleted))
+	}
+	onEvicted := c.onEvicted
+	c.Unlock()
+
+	notify(onEvicted, removed)
+}
 
-		if c.items == nil {
+// Len returns number of elements in cache.
+// Expired elements are counted until they are removed by GC.
+func (c *Cache[T]) Len() int {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.lru.Len()
+}
+
+// Keys returns keys of not expired elements from the most to the least recently used.
+func (c *Cache[T]) Keys() []string {
+	entries := c.liveEntries()
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Range calls fn for every not expired element from the most to the least recently used
+// until fn returns false. Elements are copied before iteration, so fn may modify cache.
+func (c *Cache[T]) Range(fn func(k string, v T) bool) {
+	for _, e := range c.liveEntries() {
+		if !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// Flush removes all elements from cache.
+func (c *Cache[T]) Flush() {
+	c.Lock()
+	var removed []evicted[T]
+	if c.onEvicted != nil {
+		removed = make([]evicted[T], 0, c.lru.Len())
+		for el := c.lru.Front(); el != nil; el = el.Next() {
+			e := el.Value.(*entry[T]) //nolint:forcetypeassert
+			removed = append(removed, evicted[T]{key: e.key, value: e.item.Value, reason: EvictionDeleted})
+		}
+	}
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.memory = 0
+	onEvicted := c.onEvicted
+	c.Unlock()
+
+	notify(onEvicted, removed)
+}
+
+// OnEvicted sets function called for every element removed from cache by expiration, capacity or deletion.
+// Replacing element by Set is not an eviction. fn is called without lock held, so it may use cache.
+func (c *Cache[T]) OnEvicted(fn EvictedFunc[T]) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.onEvicted = fn
+}
+
+// Close stops GC goroutine. Cache remains usable, but expired elements are not removed anymore.
+func (c *Cache[T]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+func notify[T any](onEvicted EvictedFunc[T], removed []evicted[T]) {
+	if onEvicted == nil {
+		return
+	}
+	for _, e := range removed {
+		onEvicted(e.key, e.value, e.reason)
+	}
+}
+
+func (c *Cache[T]) bounded() bool {
+	return c.maxEntries > 0 || c.maxMemory > 0
+}
+
+// evictOverflow removes least recently used elements while cache exceeds its limits.
+// Must be called under write lock.
+func (c *Cache[T]) evictOverflow() (removed []evicted[T]) {
+	for c.lru.Len() > 0 {
+		overEntries := c.maxEntries > 0 && c.lru.Len() > c.maxEntries
+		overMemory := c.maxMemory > 0 && c.memory > c.maxMemory
+		if !overEntries && !overMemory {
 			return
 		}
+		removed = append(removed, c.removeElement(c.lru.Back(), EvictionCapacity))
+	}
+	return
+}
+
+func (c *Cache[T]) removeElement(el *list.Element, reason EvictionReason) evicted[T] {
+	e := c.lru.Remove(el).(*entry[T]) //nolint:forcetypeassert
+	delete(c.items, e.key)
+	c.memory -= e.size
+	return evicted[T]{key: e.key, value: e.item.Value, reason: reason}
+}
+
+// StartGC removes expired elements every cleanup interval until Close is called.
+func (c *Cache[T]) StartGC() {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
 
-		if keys := c.expiredKeys(); len(keys) != 0 {
-			c.clearItems(keys)
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if keys := c.expiredKeys(); len(keys) != 0 {
+				c.clearItems(keys)
+			}
 		}
 	}
 }
@@ -91,8 +349,8 @@ func (c *Cache[T]) expiredKeys() (keys []string) {
 
 	defer c.RUnlock()
 
-	for k, i := range c.items {
-		if time.Now().UnixNano() > i.Expiration && i.Expiration > 0 {
+	for k, el := range c.items {
+		if c.isRemovable(el.Value.(*entry[T]).item) { //nolint:forcetypeassert
 			keys = append(keys, k)
 		}
 	}
@@ -101,10 +359,24 @@ func (c *Cache[T]) expiredKeys() (keys []string) {
 
 func (c *Cache[T]) clearItems(keys []string) {
 	c.Lock()
-
-	defer c.Unlock()
-
+	var removed []evicted[T]
 	for _, k := range keys {
-		delete(c.items, k)
+		// Element could be updated after expiredKeys call, so check it again
+		if el, ok := c.items[k]; ok && c.isRemovable(el.Value.(*entry[T]).item) { //nolint:forcetypeassert
+			removed = append(removed, c.removeElement(el, EvictionExpired))
+		}
 	}
+	onEvicted := c.onEvicted
+	c.Unlock()
+
+	notify(onEvicted, removed)
+}
+
+// isRemovable reports whether item is expired and its stale grace time passed.
+func (c *Cache[T]) isRemovable(item Item[T]) bool {
+	return item.Expiration > 0 && time.Now().UnixNano() > item.Expiration+c.staleGrace
+}
+
+func isExpired[T any](item Item[T]) bool {
+	return item.Expiration > 0 && time.Now().UnixNano() > item.Expiration
 }
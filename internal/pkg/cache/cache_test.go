@@ -0,0 +1,274 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetGet(t *testing.T) {
+	c := NewCache[string](&Config{DefaultExpirationTime: 60})
+
+	c.Set("key", "value", 0)
+
+	value, ok := c.Get("key")
+	require.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	_, ok = c.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestCache_Get_Expired(t *testing.T) {
+	c := NewCache[string](&Config{DefaultExpirationTime: 60})
+
+	c.Set("key", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestCache_GetStale(t *testing.T) {
+	c := NewCache[string](&Config{DefaultExpirationTime: 60, StaleGraceTime: 60})
+
+	c.Set("fresh", "value", 0)
+	c.Set("stale", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.GetStale("fresh")
+	assert.False(t, ok)
+	_, ok = c.Get("stale")
+	assert.False(t, ok)
+	value, ok := c.GetStale("stale")
+	require.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	// Stale items are kept by GC until grace time passes
+	assert.Empty(t, c.expiredKeys())
+}
+
+func TestCache_GetStale_GraceTimePassed(t *testing.T) {
+	c := NewCache[string](&Config{DefaultExpirationTime: 60})
+
+	c.Set("key", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.GetStale("key")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"key"}, c.expiredKeys())
+}
+
+func TestCache_Delete(t *testing.T) {
+	c := NewCache[string](&Config{DefaultExpirationTime: 60, MaxMemory: 1 << 20})
+
+	c.Set("key", "value", 0)
+	c.Delete("key")
+	c.Delete("missing")
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+	assert.Zero(t, c.lru.Len())
+	assert.Zero(t, c.memory)
+}
+
+func TestCache_MaxEntries_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 2})
+
+	c.Set("first", 1, 0)
+	c.Set("second", 2, 0)
+	// Get counts as use, so "second" becomes the least recently used
+	_, ok := c.Get("first")
+	require.True(t, ok)
+	c.Set("third", 3, 0)
+
+	_, ok = c.Get("second")
+	assert.False(t, ok)
+	_, ok = c.Get("first")
+	assert.True(t, ok)
+	_, ok = c.Get("third")
+	assert.True(t, ok)
+	assert.Len(t, c.items, 2)
+}
+
+func TestCache_MaxEntries_UpdateDoesNotEvict(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 2})
+
+	c.Set("first", 1, 0)
+	c.Set("second", 2, 0)
+	c.Set("first", 10, 0)
+
+	value, ok := c.Get("first")
+	require.True(t, ok)
+	assert.Equal(t, 10, value)
+	_, ok = c.Get("second")
+	assert.True(t, ok)
+}
+
+func TestCache_MaxMemory(t *testing.T) {
+	value := string(make([]byte, 1024))
+	itemSize := entrySize("key-0", value)
+	c := NewCache[string](&Config{DefaultExpirationTime: 60, MaxMemory: 3 * itemSize})
+
+	for i := range 10 {
+		c.Set("key-"+strconv.Itoa(i), value, 0)
+	}
+
+	assert.LessOrEqual(t, c.memory, 3*itemSize)
+	assert.Len(t, c.items, 3)
+	_, ok := c.Get("key-9")
+	assert.True(t, ok)
+	_, ok = c.Get("key-0")
+	assert.False(t, ok)
+}
+
+func TestCache_ClearItems_KeepsUpdated(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxMemory: 1 << 20})
+
+	c.Set("key", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	keys := c.expiredKeys()
+	require.Equal(t, []string{"key"}, keys)
+
+	c.Set("key", 2, 0)
+	c.clearItems(keys)
+
+	value, ok := c.Get("key")
+	require.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, entrySize("key", 2), c.memory)
+}
+
+func TestCache_LenKeysRange(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	c.Set("first", 1, 0)
+	c.Set("second", 2, 0)
+	c.Set("expired", 3, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// Expired element is counted until GC removes it, but is not listed
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, []string{"second", "first"}, c.Keys())
+
+	values := map[string]int{}
+	c.Range(func(k string, v int) bool {
+		values[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]int{"first": 1, "second": 2}, values)
+
+	calls := 0
+	c.Range(func(string, int) bool {
+		calls++
+		return false
+	})
+	assert.Equal(t, 1, calls)
+}
+
+func TestCache_Range_AllowsModification(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	c.Set("first", 1, 0)
+	c.Set("second", 2, 0)
+
+	c.Range(func(k string, _ int) bool {
+		c.Delete(k)
+		return true
+	})
+
+	assert.Zero(t, c.Len())
+}
+
+func TestCache_Flush(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxMemory: 1 << 20})
+	c.Set("first", 1, 0)
+	c.Set("second", 2, 0)
+
+	c.Flush()
+
+	assert.Zero(t, c.Len())
+	assert.Zero(t, c.memory)
+	_, ok := c.Get("first")
+	assert.False(t, ok)
+
+	c.Set("third", 3, 0)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCache_OnEvicted(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 2})
+	evictions := map[string]EvictionReason{}
+	c.OnEvicted(func(k string, _ int, reason EvictionReason) {
+		evictions[k] = reason
+		// Callback is called without lock, so cache is usable from it
+		_ = c.Len()
+	})
+
+	c.Set("expired", 1, time.Millisecond)
+	c.Set("deleted", 2, 0)
+	c.Set("deleted", 20, 0) // replacement is not an eviction
+	time.Sleep(5 * time.Millisecond)
+	c.clearItems(c.expiredKeys())
+	c.Set("first", 3, 0)
+	c.Set("second", 4, 0) // evicts "deleted" as least recently used
+	c.Set("deleted", 5, 0)
+	c.Delete("deleted")
+	c.Flush()
+
+	assert.Equal(t, map[string]EvictionReason{
+		"expired": EvictionExpired,
+		"deleted": EvictionDeleted,
+		"first":   EvictionCapacity,
+		"second":  EvictionDeleted,
+	}, evictions)
+}
+
+func TestCache_OnEvicted_Reasons(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 1})
+	var reasons []EvictionReason
+	c.OnEvicted(func(_ string, _ int, reason EvictionReason) {
+		reasons = append(reasons, reason)
+	})
+
+	c.Set("first", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.clearItems(c.expiredKeys())
+	c.Set("second", 2, 0)
+	c.Set("third", 3, 0)
+	c.Delete("third")
+
+	assert.Equal(t, []EvictionReason{EvictionExpired, EvictionCapacity, EvictionDeleted}, reasons)
+	assert.Equal(t, "expired capacity deleted", reasons[0].String()+" "+reasons[1].String()+" "+reasons[2].String())
+}
+
+func TestCache_Close_StopsGC(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	done := make(chan struct{})
+	go func() {
+		c.StartGC()
+		close(done)
+	}()
+
+	c.Close()
+	c.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("GC is not stopped by Close")
+	}
+}
+
+func TestApproximateSize(t *testing.T) {
+	type nested struct {
+		Name  string
+		Items []string
+	}
+
+	small := entrySize("k", nested{Name: "a"})
+	big := entrySize("k", nested{Name: "a", Items: []string{string(make([]byte, 4096))}})
+
+	assert.Greater(t, big-small, int64(4096))
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"io"
+	"time"
+)
+
+// ShardedCache splits keys between independently locked Cache segments,
+// so writers and GC of one shard do not block readers of others.
+type ShardedCache[T any] struct {
+	shards []*Cache[T]
+}
+
+func NewShardedCache[T any](config *Config) *ShardedCache[T] {
+	count := max(config.Shards, 1)
+	// Every shard must hold at least one element, otherwise its limit would mean unlimited
+	if config.MaxEntries > 0 {
+		count = min(count, config.MaxEntries)
+	}
+	if config.MaxMemory > 0 {
+		count = int(min(int64(count), config.MaxMemory))
+	}
+
+	// Limits are split so that limits of shards sum exactly to configured ones
+	shards := make([]*Cache[T], count)
+	for i := range shards {
+		shardConfig := *config
+		if config.MaxEntries > 0 {
+			shardConfig.MaxEntries = int(splitLimit(int64(config.MaxEntries), count, i))
+		}
+		if config.MaxMemory > 0 {
+			shardConfig.MaxMemory = splitLimit(config.MaxMemory, count, i)
+		}
+		shards[i] = NewCache[T](&shardConfig)
+	}
+	return &ShardedCache[T]{shards: shards}
+}
+
+// splitLimit returns part of limit for shard i of count. Remainder is spread over the first shards.
+func splitLimit(limit int64, count, i int) int64 {
+	res := limit / int64(count)
+	if int64(i) < limit%int64(count) {
+		res++
+	}
+	return res
+}
+
+// Set Add element to cache shard selected by key.
+// If expiration == 0 default expiration time will be used.
+func (c *ShardedCache[T]) Set(k string, v T, expiration time.Duration) {
+	c.shard(k).Set(k, v, expiration)
+}
+
+func (c *ShardedCache[T]) Get(k string) (T, bool) { //nolint:ireturn
+	return c.shard(k).Get(k)
+}
+
+func (c *ShardedCache[T]) GetStale(k string) (T, bool) { //nolint:ireturn
+	return c.shard(k).GetStale(k)
+}
+
+func (c *ShardedCache[T]) Delete(k string) {
+	c.shard(k).Delete(k)
+}
+
+// Len returns number of elements in all shards.
+func (c *ShardedCache[T]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+// Keys returns keys of not expired elements. Order is kept within a shard only.
+func (c *ShardedCache[T]) Keys() []string {
+	var keys []string
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys()...)
+	}
+	return keys
+}
+
+// Range calls fn for every not expired element shard by shard until fn returns false.
+func (c *ShardedCache[T]) Range(fn func(k string, v T) bool) {
+	stopped := false
+	for _, s := range c.shards {
+		s.Range(func(k string, v T) bool {
+			stopped = !fn(k, v)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+func (c *ShardedCache[T]) Flush() {
+	for _, s := range c.shards {
+		s.Flush()
+	}
+}
+
+// OnEvicted sets eviction callback of every shard.
+func (c *ShardedCache[T]) OnEvicted(fn EvictedFunc[T]) {
+	for _, s := range c.shards {
+		s.OnEvicted(fn)
+	}
+}
+
+// Close stops GC goroutines of all shards.
+func (c *ShardedCache[T]) Close() {
+	for _, s := range c.shards {
+		s.Close()
+	}
+}
+
+// Dump writes live elements of all shards to w as a single snapshot.
+func (c *ShardedCache[T]) Dump(w io.Writer) error {
+	var entries []snapshotEntry[T]
+	for _, s := range c.shards {
+		entries = append(entries, s.liveEntries()...)
+	}
+	return encodeSnapshot(w, entries)
+}
+
+// Load restores snapshot written by Dump of either Cache or ShardedCache.
+// Elements are distributed by key, so snapshot does not depend on number of shards.
+func (c *ShardedCache[T]) Load(r io.Reader) (int, error) {
+	// Shards are dumped one after another, so entries are not limited here,
+	// every shard evicts its overflow by itself
+	entries, err := decodeSnapshot[T](r, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	byShard := make(map[*Cache[T]][]snapshotEntry[T], len(c.shards))
+	for _, e := range entries {
+		s := c.shard(e.Key)
+		byShard[s] = append(byShard[s], e)
+	}
+	restored := 0
+	for s, shardEntries := range byShard {
+		restored += s.restore(shardEntries)
+	}
+	return restored, nil
+}
+
+func (c *ShardedCache[T]) shard(k string) *Cache[T] {
+	return c.shards[fnv32a(k)%uint32(len(c.shards))] //nolint:gosec
+}
+
+// fnv32a is an allocation free FNV-1a hash.
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := range len(s) {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
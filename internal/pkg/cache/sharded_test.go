@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchCache interface {
+	Set(k string, v int, expiration time.Duration)
+	Get(k string) (int, bool)
+}
+
+const benchKeys = 1 << 14
+
+func TestShardedCache_SetGet(t *testing.T) {
+	c := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 8})
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+	for i := range 100 {
+		value, ok := c.Get(strconv.Itoa(i))
+		require.True(t, ok)
+		assert.Equal(t, i, value)
+	}
+	_, ok := c.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestShardedCache_SplitsLimits(t *testing.T) {
+	c := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 4, MaxEntries: 40})
+
+	for i := range 1000 {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+
+	total := 0
+	for _, shard := range c.shards {
+		assert.LessOrEqual(t, len(shard.items), 10)
+		total += len(shard.items)
+	}
+	assert.LessOrEqual(t, total, 40)
+}
+
+func TestNewShardedCache_LimitsSumToConfigured(t *testing.T) {
+	tests := []struct {
+		name       string
+		shards     int
+		maxEntries int
+		wantShards int
+	}{
+		{"remainder", 4, 42, 4},
+		{"fewer entries than shards", 8, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewShardedCache[int](&Config{
+				DefaultExpirationTime: 60, Shards: tt.shards, MaxEntries: tt.maxEntries, MaxMemory: 1000,
+			})
+
+			require.Len(t, c.shards, tt.wantShards)
+			var entries int
+			var memory int64
+			for _, shard := range c.shards {
+				assert.Positive(t, shard.maxEntries)
+				entries += shard.maxEntries
+				memory += shard.maxMemory
+			}
+			assert.Equal(t, tt.maxEntries, entries)
+			assert.Equal(t, int64(1000), memory)
+		})
+	}
+}
+
+func TestNewShardedCache_AtLeastOneShard(t *testing.T) {
+	c := NewShardedCache[int](&Config{DefaultExpirationTime: 60})
+
+	assert.Len(t, c.shards, 1)
+}
+
+func TestShardedCache_Lifecycle(t *testing.T) {
+	c := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 4})
+	deleted := map[string]int{}
+	c.OnEvicted(func(k string, v int, reason EvictionReason) {
+		if reason == EvictionDeleted {
+			deleted[k] = v
+		}
+	})
+	for i := range 10 {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+
+	assert.Equal(t, 10, c.Len())
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, c.Keys())
+
+	calls := 0
+	c.Range(func(string, int) bool {
+		calls++
+		return calls < 3
+	})
+	assert.Equal(t, 3, calls)
+
+	c.Delete("0")
+	c.Flush()
+	c.Close()
+
+	assert.Zero(t, c.Len())
+	assert.Len(t, deleted, 10)
+}
+
+func BenchmarkCache_Get(b *testing.B) {
+	benchmarkGet(b, NewCache[int](&Config{DefaultExpirationTime: 60}))
+}
+
+func BenchmarkShardedCache_Get(b *testing.B) {
+	benchmarkGet(b, NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 16}))
+}
+
+func BenchmarkCache_Mixed(b *testing.B) {
+	benchmarkMixed(b, NewCache[int](&Config{DefaultExpirationTime: 60}))
+}
+
+func BenchmarkShardedCache_Mixed(b *testing.B) {
+	benchmarkMixed(b, NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 16}))
+}
+
+func BenchmarkCache_Mixed_Bounded(b *testing.B) {
+	benchmarkMixed(b, NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: benchKeys / 2}))
+}
+
+func BenchmarkShardedCache_Mixed_Bounded(b *testing.B) {
+	benchmarkMixed(b, NewShardedCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: benchKeys / 2, Shards: 16}))
+}
+
+func benchmarkKeys() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "order-" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func benchmarkGet(b *testing.B, c benchCache) {
+	b.Helper()
+	keys := benchmarkKeys()
+	for i, k := range keys {
+		c.Set(k, i, 0)
+	}
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(keys[i%benchKeys])
+			i++
+		}
+	})
+}
+
+// benchmarkMixed runs 90% reads and 10% writes.
+func benchmarkMixed(b *testing.B, c benchCache) {
+	b.Helper()
+	keys := benchmarkKeys()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k := keys[i%benchKeys]
+			if i%10 == 0 {
+				c.Set(k, i, 0)
+			} else {
+				c.Get(k)
+			}
+			i++
+		}
+	})
+}
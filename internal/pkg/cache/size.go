@@ -0,0 +1,55 @@
+package cache
+
+import "reflect"
+
+// entryOverhead approximates memory used by map bucket, list element and entry header.
+const entryOverhead = 128
+
+// entrySize returns approximate memory used by cache entry with key k and value v.
+func entrySize(k string, v any) int64 {
+	return entryOverhead + int64(len(k)) + approximateSize(reflect.ValueOf(v))
+}
+
+// approximateSize estimates memory held by value: its own size plus
+// data reachable through strings, slices, maps and pointers.
+// Unexported fields are counted only by their own size.
+func approximateSize(v reflect.Value) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	return int64(v.Type().Size()) + referencedSize(v)
+}
+
+func referencedSize(v reflect.Value) int64 {
+	var size int64
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		size = int64(v.Len())
+	case reflect.Slice:
+		size = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := range v.Len() {
+			size += referencedSize(v.Index(i))
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			size += referencedSize(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			if t.Field(i).IsExported() {
+				size += referencedSize(v.Field(i))
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			size += approximateSize(iter.Key()) + approximateSize(iter.Value())
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			size = approximateSize(v.Elem())
+		}
+	}
+	return size
+}
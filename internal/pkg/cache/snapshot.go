@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotVersion is a version of snapshot format.
+// It must be increased on any incompatible change of snapshot layout or cached types.
+// Version 2 adds version of cached orders.
+const SnapshotVersion = 2
+
+// maxSnapshotPrealloc limits entries allocated before they are decoded,
+// so corrupted count of snapshot header can't exhaust memory.
+const maxSnapshotPrealloc = 1024
+
+var (
+	ErrSnapshotVersion   = errors.New("unsupported cache snapshot version")
+	ErrSnapshotCorrupted = errors.New("corrupted cache snapshot")
+)
+
+// Snapshotter is implemented by caches which can be dumped to and restored from snapshot.
+type Snapshotter interface {
+	Dump(w io.Writer) error
+	Load(r io.Reader) (int, error)
+}
+
+type snapshotHeader struct {
+	Version int
+	Count   int
+}
+
+type snapshotEntry[T any] struct {
+	Key        string
+	Value      T
+	Expiration int64
+}
+
+// Dump writes live elements with their expirations to w.
+// Elements are written from the most to the least recently used.
+func (c *Cache[T]) Dump(w io.Writer) error {
+	return encodeSnapshot(w, c.liveEntries())
+}
+
+// Load restores elements written by Dump and returns number of restored elements.
+// Elements which expired since dump are skipped. Snapshot of other version is rejected with ErrSnapshotVersion.
+func (c *Cache[T]) Load(r io.Reader) (int, error) {
+	entries, err := decodeSnapshot[T](r, c.maxEntries)
+	if err != nil {
+		return 0, err
+	}
+	return c.restore(entries), nil
+}
+
+func encodeSnapshot[T any](w io.Writer, entries []snapshotEntry[T]) error {
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: SnapshotVersion, Count: len(entries)}); err != nil {
+		return fmt.Errorf("encode snapshot header: %w", err)
+	}
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return fmt.Errorf("encode snapshot entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Cache[T]) liveEntries() []snapshotEntry[T] {
+	c.RLock()
+	defer c.RUnlock()
+
+	entries := make([]snapshotEntry[T], 0, c.lru.Len())
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*entry[T]) //nolint:forcetypeassert
+		if isExpired(e.item) {
+			continue
+		}
+		entries = append(entries, snapshotEntry[T]{Key: e.key, Value: e.item.Value, Expiration: e.item.Expiration})
+	}
+	return entries
+}
+
+// restore sets entries keeping their original expiration.
+// Entries are added from the least recently used, so recency order survives restart.
+func (c *Cache[T]) restore(entries []snapshotEntry[T]) int {
+	restored := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		ttl := time.Until(time.Unix(0, e.Expiration))
+		if ttl <= 0 {
+			continue
+		}
+		c.Set(e.Key, e.Value, ttl)
+		restored++
+	}
+	return restored
+}
+
+// decodeSnapshot reads at most limit entries of snapshot, if limit is positive.
+// Entries are dumped from the most recently used, so the rest would be evicted on restore anyway.
+func decodeSnapshot[T any](r io.Reader, limit int) ([]snapshotEntry[T], error) {
+	dec := gob.NewDecoder(r)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("decode snapshot header: %w", err)
+	}
+	if header.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrSnapshotVersion, header.Version, SnapshotVersion)
+	}
+	if header.Count < 0 {
+		return nil, fmt.Errorf("%w: negative entry count %d", ErrSnapshotCorrupted, header.Count)
+	}
+
+	count := header.Count
+	if limit > 0 {
+		count = min(count, limit)
+	}
+	entries := make([]snapshotEntry[T], 0, min(count, maxSnapshotPrealloc))
+	for range count {
+		var e snapshotEntry[T]
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("decode snapshot entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// SaveSnapshot dumps cache to file at path.
+// Snapshot is written to temporary file first, so crash during dump does not corrupt previous snapshot.
+func SaveSnapshot(path string, c Snapshotter) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if err := c.Dump(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace snapshot file: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot restores cache from file at path and returns number of restored elements.
+// Missing file is reported with error matching fs.ErrNotExist.
+func LoadSnapshot(path string, c Snapshotter) (int, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return 0, fmt.Errorf("open snapshot file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	return c.Load(f)
+}
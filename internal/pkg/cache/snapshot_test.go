@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotValue struct {
+	Name    string
+	Items   []string
+	Created time.Time
+}
+
+func TestCache_DumpLoad(t *testing.T) {
+	src := NewCache[snapshotValue](&Config{DefaultExpirationTime: 60})
+	value := snapshotValue{Name: "a", Items: []string{"x", "y"}, Created: time.Now().UTC()}
+	src.Set("key", value, 0)
+	src.Set("expired", value, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Dump(&buf))
+
+	dst := NewCache[snapshotValue](&Config{DefaultExpirationTime: 600})
+	restored, err := dst.Load(&buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	got, ok := dst.Get("key")
+	require.True(t, ok)
+	assert.Equal(t, value.Name, got.Name)
+	assert.Equal(t, value.Items, got.Items)
+	assert.True(t, value.Created.Equal(got.Created))
+	_, ok = dst.GetStale("expired")
+	assert.False(t, ok)
+
+	// Expiration is kept from the source cache, not taken from destination defaults
+	srcItem := src.items["key"].Value.(*entry[snapshotValue]).item //nolint:forcetypeassert
+	dstItem := dst.items["key"].Value.(*entry[snapshotValue]).item //nolint:forcetypeassert
+	assert.InDelta(t, srcItem.Expiration, dstItem.Expiration, float64(time.Second))
+}
+
+func TestCache_Load_SkipsExpiredSinceDump(t *testing.T) {
+	src := NewCache[int](&Config{DefaultExpirationTime: 60})
+	src.Set("short", 1, 20*time.Millisecond)
+	src.Set("long", 2, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Dump(&buf))
+	time.Sleep(30 * time.Millisecond)
+
+	dst := NewCache[int](&Config{DefaultExpirationTime: 60})
+	restored, err := dst.Load(&buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	_, ok := dst.Get("short")
+	assert.False(t, ok)
+	_, ok = dst.Get("long")
+	assert.True(t, ok)
+}
+
+func TestCache_Load_KeepsRecencyOrder(t *testing.T) {
+	src := NewCache[int](&Config{DefaultExpirationTime: 60})
+	src.Set("old", 1, 0)
+	src.Set("new", 2, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Dump(&buf))
+
+	// Only the most recently used element fits into smaller cache
+	dst := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 1})
+	_, err := dst.Load(&buf)
+
+	require.NoError(t, err)
+	_, ok := dst.Get("new")
+	assert.True(t, ok)
+	_, ok = dst.Get("old")
+	assert.False(t, ok)
+}
+
+func TestCache_Load_VersionMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(snapshotHeader{Version: SnapshotVersion + 1}))
+
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	restored, err := c.Load(&buf)
+
+	require.ErrorIs(t, err, ErrSnapshotVersion)
+	assert.Zero(t, restored)
+}
+
+func TestCache_Load_Corrupted(t *testing.T) {
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+
+	_, err := c.Load(bytes.NewBufferString("not a snapshot"))
+
+	require.Error(t, err)
+	assert.Empty(t, c.items)
+}
+
+func TestCache_Load_CountExceedsEntries(t *testing.T) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	require.NoError(t, enc.Encode(snapshotHeader{Version: SnapshotVersion, Count: math.MaxInt}))
+	require.NoError(t, enc.Encode(snapshotEntry[int]{Key: "key", Expiration: time.Now().Add(time.Minute).UnixNano()}))
+
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	_, err := c.Load(&buf)
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Empty(t, c.items)
+}
+
+func TestCache_Load_NegativeCount(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(snapshotHeader{Version: SnapshotVersion, Count: -1}))
+
+	c := NewCache[int](&Config{DefaultExpirationTime: 60})
+	_, err := c.Load(&buf)
+
+	require.ErrorIs(t, err, ErrSnapshotCorrupted)
+}
+
+func TestCache_Load_LimitedByMaxEntries(t *testing.T) {
+	src := NewCache[int](&Config{DefaultExpirationTime: 60})
+	for i, k := range []string{"a", "b", "c"} {
+		src.Set(k, i, 0)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Dump(&buf))
+
+	dst := NewCache[int](&Config{DefaultExpirationTime: 60, MaxEntries: 2})
+	restored, err := dst.Load(&buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+	assert.ElementsMatch(t, []string{"b", "c"}, dst.Keys())
+}
+
+func TestShardedCache_DumpLoad_DifferentShardCount(t *testing.T) {
+	src := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 4})
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		src.Set(k, i, 0)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Dump(&buf))
+
+	dst := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 3})
+	restored, err := dst.Load(&buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, restored)
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		value, ok := dst.Get(k)
+		require.True(t, ok, k)
+		assert.Equal(t, i, value)
+	}
+}
+
+func TestSaveLoadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "orders.snapshot")
+
+	_, err := LoadSnapshot(path, NewCache[int](&Config{DefaultExpirationTime: 60}))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	src := NewCache[int](&Config{DefaultExpirationTime: 60})
+	src.Set("key", 1, 0)
+	require.NoError(t, SaveSnapshot(path, src))
+
+	dst := NewShardedCache[int](&Config{DefaultExpirationTime: 60, Shards: 2})
+	restored, err := LoadSnapshot(path, dst)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	value, ok := dst.Get("key")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// Temporary files are not left next to snapshot
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, files)
+}
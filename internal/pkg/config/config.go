@@ -11,6 +11,7 @@ import (
 	"wb-L0-task/internal/pkg/kafka"
 	"wb-L0-task/internal/pkg/logger"
 	"wb-L0-task/internal/pkg/postgres"
+	"wb-L0-task/internal/pkg/schemaregistry"
 	"wb-L0-task/internal/pkg/server"
 
 	"github.com/joho/godotenv"
@@ -22,11 +23,12 @@ const defaultConfigFileName = "config.yaml"
 var ErrEmptyPath = errors.New("path to config must not be empty")
 
 type AppConfig struct {
-	Cache    *cache.Config
-	Kafka    *kafka.Config
-	Logger   *logger.Config
-	Postgres *postgres.Config
-	Server   *server.Config
+	Cache          *cache.Config
+	Kafka          *kafka.Config
+	Logger         *logger.Config
+	Postgres       *postgres.Config
+	Server         *server.Config
+	SchemaRegistry *schemaregistry.Config `mapstructure:"schema_registry"`
 }
 
 func New() (*AppConfig, error) {
@@ -75,6 +77,9 @@ func initConfig(configFile string) (*AppConfig, error) {
 	if err = viperInstance.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("v.Unmarshal: %w", err)
 	}
+	if err = cfg.Kafka.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka config: %w", err)
+	}
 
 	return cfg, nil
 }
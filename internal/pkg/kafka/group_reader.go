@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// GroupReader consumes topic as a member of consumer group and commits offsets to broker.
+// Unlike kafka.Reader it can move consumer group to another position.
+type GroupReader struct {
+	config *Config
+	topic  TopicConfig
+	dialer *kafka.Dialer
+	mu     sync.RWMutex
+	reader *kafka.Reader
+}
+
+// NewConsumer creates reader of topic, which commits offsets to broker. Config must be validated.
+func NewConsumer(config *Config, topic TopicConfig, dialer *kafka.Dialer) *GroupReader {
+	r := &GroupReader{config: config, topic: topic, dialer: dialer}
+	r.reader = kafka.NewReader(r.readerConfig())
+	return r
+}
+
+func (r *GroupReader) readerConfig() kafka.ReaderConfig {
+	group := r.config.groupConfig(r.topic, r.dialer)
+	readerConfig := r.config.readerConfig(r.topic, r.dialer)
+	readerConfig.GroupID = group.ID
+	readerConfig.GroupBalancers = group.GroupBalancers
+	readerConfig.HeartbeatInterval = group.HeartbeatInterval
+	readerConfig.SessionTimeout = group.SessionTimeout
+	readerConfig.StartOffset = group.StartOffset
+	readerConfig.CommitInterval = 0 // Sync mod for native Commit() call
+	return readerConfig
+}
+
+func (r *GroupReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reader.FetchMessage(ctx)
+}
+
+func (r *GroupReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reader.CommitMessages(ctx, msgs...)
+}
+
+func (r *GroupReader) Close() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reader.Close()
+}
+
+// Lag returns number of messages after offset committed by consumer group by partition.
+func (r *GroupReader) Lag(ctx context.Context) (map[int]int64, error) {
+	return groupLag(ctx, newClient(r.config, r.dialer), r.topic.GroupID, r.topic.Name)
+}
+
+// Seek commits offsets of position for consumer group and returns them by partition.
+// Broker accepts offsets only when group is empty, so reader leaves group and joins it again after commit.
+// Paused members of other replicas stay in group, so seek is supported only when this reader is the only member
+// of group, otherwise ErrSeekUnsupported is returned. Caller must not fetch messages while seeking.
+func (r *GroupReader) Seek(ctx context.Context, pos SeekPosition) (map[int]int64, error) {
+	client := newClient(r.config, r.dialer)
+	members, err := groupMembers(ctx, client, r.topic.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if members > 1 {
+		return nil, fmt.Errorf("%w: consumer group %q has %d members, stop other replicas to seek",
+			ErrSeekUnsupported, r.topic.GroupID, members)
+	}
+	offsets, err := resolveOffsets(ctx, client, r.topic.Name, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if closeErr := r.reader.Close(); closeErr != nil {
+		logger.Warn("Failed to leave consumer group before seek", "topic", r.topic.Name, "err", closeErr)
+	}
+	err = commitGroupOffsets(ctx, client, r.topic.GroupID, r.topic.Name, offsets)
+	r.reader = kafka.NewReader(r.readerConfig())
+	if err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
@@ -1,28 +1,289 @@
 package kafka
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
 	"github.com/segmentio/kafka-go"
 )
 
+const (
+	// defaultMaxBytes limits size of one fetch from partition, if it is not configured.
+	// It fits orders with dozens of items.
+	defaultMaxBytes = 1 << 20
+	dialTimeout     = 10 * time.Second
+)
+
+// Values of auto_offset_reset: where consumer group starts reading partition without committed offset.
+const (
+	OffsetResetEarliest = "earliest"
+	OffsetResetLatest   = "latest"
+)
+
+// Rebalance strategies, which assign partitions to members of consumer group.
+const (
+	RebalanceRange      = "range"
+	RebalanceRoundRobin = "round_robin"
+)
+
+var (
+	ErrNoBrokers                = errors.New("no brokers")
+	ErrUnknownOffsetReset       = errors.New("unknown auto offset reset")
+	ErrUnknownRebalanceStrategy = errors.New("unknown rebalance strategy")
+	ErrInvalidFetchLimits       = errors.New("invalid fetch limits")
+	ErrInvalidGroupTimeouts     = errors.New("invalid consumer group timeouts")
+	ErrDuplicateTopic           = errors.New("topic is consumed twice")
+	ErrUnknownConflictPolicy    = errors.New("unknown conflict policy")
+)
+
+// conflictPolicies are names of conflict policies of order consumer. Empty policy means reject.
+//
+//nolint:gochecknoglobals
+var conflictPolicies = []string{"", "reject", "overwrite", "newest"}
+
+// OffsetStorage tells where offsets of processed messages are stored.
+type OffsetStorage string
+
+const (
+	// OffsetStorageKafka commits offsets to broker after messages are processed.
+	OffsetStorageKafka OffsetStorage = "kafka"
+	// OffsetStoragePostgres stores offsets in the same database transaction as processing results,
+	// so every message is processed exactly once. Offsets committed to broker are advisory.
+	OffsetStoragePostgres OffsetStorage = "postgres"
+)
+
+var ErrUnknownOffsetStorage = errors.New("unknown offset storage")
+
+// Validate checks that storage is known. Empty storage means OffsetStorageKafka.
+func (s OffsetStorage) Validate() error {
+	switch s {
+	case "", OffsetStorageKafka, OffsetStoragePostgres:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOffsetStorage, s)
+	}
+}
+
 type Config struct {
-	Brokers []string `mapstructure:"brokers"`
+	Brokers []string   `mapstructure:"brokers"`
+	TLS     TLSConfig  `mapstructure:"tls"`
+	SASL    SASLConfig `mapstructure:"sasl"`
 	Topics  struct {
-		Input string `mapstructure:"input"`
+		// Orders receives order events: created, updated and deleted orders.
+		Orders TopicConfig `mapstructure:"orders"`
+		// OrderUpdates receives order updates only.
+		OrderUpdates TopicConfig `mapstructure:"order_updates"`
+		// PaymentConfirmations receives confirmed payments of orders.
+		PaymentConfirmations TopicConfig `mapstructure:"payment_confirmations"`
+		// DeadLetter receives messages rejected by consumer of any topic. Empty disables dead lettering.
+		DeadLetter string `mapstructure:"dead_letter"`
 	} `mapstructure:"topics"`
 	Consumer struct {
+		// AutoOffsetReset is one of: earliest, latest. It may be overridden for topic.
 		AutoOffsetReset string `mapstructure:"auto_offset_reset"`
-		GroupID         string `mapstructure:"group_id"`
+		// GroupID is a default consumer group of topics.
+		GroupID string `mapstructure:"group_id"`
+		// Retry describes how transient failures of message processing are retried.
+		Retry RetryConfig `mapstructure:"retry"`
+		// Batch enables batched consumption.
+		Batch BatchConfig `mapstructure:"batch"`
+		// ConflictPolicy is one of: reject, overwrite, newest. It is applied to changed orders which are already stored.
+		// Default is reject.
+		ConflictPolicy string `mapstructure:"conflict_policy"`
+		// Workers is a number of partitions processed in parallel. Values <= 1 disable parallel processing.
+		// It may be overridden for topic.
+		Workers int `mapstructure:"workers"`
+		// OffsetStorage is one of: kafka, postgres. Default is kafka.
+		OffsetStorage string `mapstructure:"offset_storage"`
+		// MinBytes and MaxBytes limit size of one fetch from partition. MaxBytes must fit the largest message.
+		MinBytes int `mapstructure:"min_bytes"`
+		MaxBytes int `mapstructure:"max_bytes"`
+		// MaxWait is a maximum time in milliseconds broker waits for MinBytes of messages.
+		MaxWait int `mapstructure:"max_wait_ms"`
+		// HeartbeatInterval and SessionTimeout in milliseconds tell how soon failed member leaves consumer group.
+		HeartbeatInterval int `mapstructure:"heartbeat_interval_ms"`
+		SessionTimeout    int `mapstructure:"session_timeout_ms"`
+		// RebalanceStrategy is one of: range, round_robin.
+		RebalanceStrategy string `mapstructure:"rebalance_strategy"`
 	} `mapstructure:"consumer"`
 }
 
-func NewConsumer(config *Config) *kafka.Reader {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        config.Brokers,
-		Topic:          config.Topics.Input,
-		GroupID:        config.Consumer.GroupID,
-		MaxBytes:       10e3,
-		CommitInterval: 0, // Sync mod for native Commit() call
-	})
+// Validate checks connection, topic and consumer settings, so misconfiguration is reported on startup.
+// All problems are reported at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Brokers) == 0 {
+		errs = append(errs, ErrNoBrokers)
+	}
+	if _, err := NewDialer(c); err != nil {
+		errs = append(errs, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, topic := range c.InputTopics() {
+		if seen[topic.Name] {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrDuplicateTopic, topic.Name))
+		}
+		seen[topic.Name] = true
+		if _, err := startOffset(topic.AutoOffsetReset); err != nil {
+			errs = append(errs, fmt.Errorf("topic %q: %w", topic.Name, err))
+		}
+	}
+	if _, err := startOffset(c.Consumer.AutoOffsetReset); err != nil {
+		errs = append(errs, err)
+	}
+
+	consumer := c.Consumer
+	if consumer.MinBytes < 0 || consumer.MaxBytes < 0 || consumer.MaxWait < 0 {
+		errs = append(errs, fmt.Errorf("%w: values must not be negative", ErrInvalidFetchLimits))
+	}
+	if consumer.MinBytes > c.maxBytes() {
+		errs = append(errs, fmt.Errorf("%w: min bytes %d exceed max bytes %d",
+			ErrInvalidFetchLimits, consumer.MinBytes, c.maxBytes()))
+	}
+	if consumer.HeartbeatInterval < 0 || consumer.SessionTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%w: values must not be negative", ErrInvalidGroupTimeouts))
+	}
+	if consumer.HeartbeatInterval > 0 && consumer.SessionTimeout > 0 &&
+		consumer.HeartbeatInterval >= consumer.SessionTimeout {
+		errs = append(errs, fmt.Errorf("%w: heartbeat interval must be less than session timeout",
+			ErrInvalidGroupTimeouts))
+	}
+	if _, err := groupBalancers(consumer.RebalanceStrategy); err != nil {
+		errs = append(errs, err)
+	}
+	if err := OffsetStorage(consumer.OffsetStorage).Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if !slices.Contains(conflictPolicies, consumer.ConflictPolicy) {
+		errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownConflictPolicy, consumer.ConflictPolicy))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) maxBytes() int {
+	if c.Consumer.MaxBytes == 0 {
+		return defaultMaxBytes
+	}
+	return c.Consumer.MaxBytes
+}
+
+// startOffset returns offset where partition without committed offset is read from.
+func startOffset(autoOffsetReset string) (int64, error) {
+	switch autoOffsetReset {
+	case "", OffsetResetEarliest:
+		return kafka.FirstOffset, nil
+	case OffsetResetLatest:
+		return kafka.LastOffset, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownOffsetReset, autoOffsetReset)
+	}
+}
+
+func groupBalancers(strategy string) ([]kafka.GroupBalancer, error) {
+	switch strategy {
+	case "", RebalanceRange:
+		return []kafka.GroupBalancer{kafka.RangeGroupBalancer{}}, nil
+	case RebalanceRoundRobin:
+		return []kafka.GroupBalancer{kafka.RoundRobinGroupBalancer{}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRebalanceStrategy, strategy)
+	}
+}
+
+// groupConfig returns consumer group settings of topic. Config must be validated.
+func (c *Config) groupConfig(topic TopicConfig, dialer *kafka.Dialer) kafka.ConsumerGroupConfig {
+	offset, _ := startOffset(topic.AutoOffsetReset)
+	balancers, _ := groupBalancers(c.Consumer.RebalanceStrategy)
+	return kafka.ConsumerGroupConfig{
+		ID:                topic.GroupID,
+		Brokers:           c.Brokers,
+		Dialer:            dialer,
+		Topics:            []string{topic.Name},
+		GroupBalancers:    balancers,
+		HeartbeatInterval: time.Duration(c.Consumer.HeartbeatInterval) * time.Millisecond,
+		SessionTimeout:    time.Duration(c.Consumer.SessionTimeout) * time.Millisecond,
+		StartOffset:       offset,
+	}
+}
+
+// readerConfig returns settings of reader of topic. Config must be validated.
+func (c *Config) readerConfig(topic TopicConfig, dialer *kafka.Dialer) kafka.ReaderConfig {
+	return kafka.ReaderConfig{
+		Brokers:  c.Brokers,
+		Dialer:   dialer,
+		Topic:    topic.Name,
+		MinBytes: c.Consumer.MinBytes,
+		MaxBytes: c.maxBytes(),
+		MaxWait:  time.Duration(c.Consumer.MaxWait) * time.Millisecond,
+	}
+}
+
+// TopicConfig describes consumed topic. Consumer group settings which are not set are taken from Consumer.
+type TopicConfig struct {
+	// Name of topic. Empty name disables consumption of topic.
+	Name string `mapstructure:"name"`
+	// GroupID of consumer group reading topic.
+	GroupID string `mapstructure:"group_id"`
+	// Workers is a number of partitions of topic processed in parallel.
+	Workers int `mapstructure:"workers"`
+	// AutoOffsetReset is one of: earliest, latest.
+	AutoOffsetReset string `mapstructure:"auto_offset_reset"`
+}
+
+// InputTopics returns consumed topics with consumer group settings defaulted from Consumer.
+func (c *Config) InputTopics() []TopicConfig {
+	var res []TopicConfig
+	for _, topic := range []TopicConfig{c.Topics.Orders, c.Topics.OrderUpdates, c.Topics.PaymentConfirmations} {
+		if topic.Name == "" {
+			continue
+		}
+		if topic.GroupID == "" {
+			topic.GroupID = c.Consumer.GroupID
+		}
+		if topic.Workers == 0 {
+			topic.Workers = c.Consumer.Workers
+		}
+		if topic.AutoOffsetReset == "" {
+			topic.AutoOffsetReset = c.Consumer.AutoOffsetReset
+		}
+		res = append(res, topic)
+	}
+	return res
+}
+
+type BatchConfig struct {
+	// Size is a maximum number of messages saved in one transaction. Values <= 1 disable batching.
+	Size int `mapstructure:"size"`
+	// Timeout is a maximum time in milliseconds to collect batch after its first message.
+	Timeout int `mapstructure:"timeout_ms"`
+}
+
+type RetryConfig struct {
+	// InitialInterval is a delay before the first retry in milliseconds.
+	InitialInterval int `mapstructure:"initial_interval_ms"`
+	// MaxInterval caps delay between retries in milliseconds.
+	MaxInterval int `mapstructure:"max_interval_ms"`
+	// Multiplier increases delay after every retry.
+	Multiplier float64 `mapstructure:"multiplier"`
+	// Jitter is a fraction of delay which is randomized, in range [0, 1].
+	Jitter float64 `mapstructure:"jitter"`
+	// MaxRetries limits number of retries of one message. 0 means retry until shutdown.
+	// Limit is applied only if dead letter topic is set, otherwise message would be lost.
+	MaxRetries int `mapstructure:"max_retries"`
+}
 
-	return reader
+// NewDeadLetterProducer creates writer to dead letter topic.
+// Messages with the same key are written to the same partition, so their order is kept.
+func NewDeadLetterProducer(config *Config, dialer *kafka.Dialer) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(config.Brokers...),
+		Topic:                  config.Topics.DeadLetter,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		Transport:              newTransport(dialer),
+	}
 }
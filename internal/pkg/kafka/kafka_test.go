@@ -0,0 +1,178 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_InputTopics(t *testing.T) {
+	cfg := &Config{}
+	cfg.Consumer.GroupID = "orders-group"
+	cfg.Consumer.Workers = 4
+	cfg.Topics.Orders = TopicConfig{Name: "orders"}
+	cfg.Topics.OrderUpdates = TopicConfig{Name: "order-updates", GroupID: "updates-group", Workers: 1}
+
+	assert.Equal(t, []TopicConfig{
+		{Name: "orders", GroupID: "orders-group", Workers: 4},
+		{Name: "order-updates", GroupID: "updates-group", Workers: 1},
+	}, cfg.InputTopics())
+}
+
+func validConfig() *Config {
+	cfg := &Config{Brokers: []string{"localhost:9092"}}
+	cfg.Topics.Orders = TopicConfig{Name: "orders"}
+	cfg.Consumer.GroupID = "orders-group"
+	return cfg
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr error
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{name: "tuned", modify: func(cfg *Config) {
+			cfg.Consumer.AutoOffsetReset = OffsetResetLatest
+			cfg.Consumer.MinBytes = 1
+			cfg.Consumer.MaxBytes = 10e6
+			cfg.Consumer.MaxWait = 500
+			cfg.Consumer.HeartbeatInterval = 3000
+			cfg.Consumer.SessionTimeout = 30000
+			cfg.Consumer.RebalanceStrategy = RebalanceRoundRobin
+			cfg.Consumer.OffsetStorage = string(OffsetStoragePostgres)
+			cfg.Consumer.ConflictPolicy = "newest"
+			cfg.SASL = SASLConfig{Mechanism: SASLScramSHA512, Username: "user", Password: "secret"}
+		}},
+		{name: "no brokers", modify: func(cfg *Config) { cfg.Brokers = nil }, wantErr: ErrNoBrokers},
+		{
+			name:    "unknown offset reset",
+			modify:  func(cfg *Config) { cfg.Consumer.AutoOffsetReset = "smallest" },
+			wantErr: ErrUnknownOffsetReset,
+		},
+		{
+			name:    "unknown offset reset of topic",
+			modify:  func(cfg *Config) { cfg.Topics.Orders.AutoOffsetReset = "none" },
+			wantErr: ErrUnknownOffsetReset,
+		},
+		{
+			name:    "duplicate topic",
+			modify:  func(cfg *Config) { cfg.Topics.OrderUpdates = TopicConfig{Name: "orders"} },
+			wantErr: ErrDuplicateTopic,
+		},
+		{
+			name:    "min bytes exceed default max bytes",
+			modify:  func(cfg *Config) { cfg.Consumer.MinBytes = defaultMaxBytes + 1 },
+			wantErr: ErrInvalidFetchLimits,
+		},
+		{
+			name:    "negative max wait",
+			modify:  func(cfg *Config) { cfg.Consumer.MaxWait = -1 },
+			wantErr: ErrInvalidFetchLimits,
+		},
+		{
+			name: "heartbeat not less than session timeout",
+			modify: func(cfg *Config) {
+				cfg.Consumer.HeartbeatInterval = 10000
+				cfg.Consumer.SessionTimeout = 10000
+			},
+			wantErr: ErrInvalidGroupTimeouts,
+		},
+		{
+			name:    "unknown rebalance strategy",
+			modify:  func(cfg *Config) { cfg.Consumer.RebalanceStrategy = "sticky" },
+			wantErr: ErrUnknownRebalanceStrategy,
+		},
+		{
+			name:    "unknown offset storage",
+			modify:  func(cfg *Config) { cfg.Consumer.OffsetStorage = "redis" },
+			wantErr: ErrUnknownOffsetStorage,
+		},
+		{
+			name:    "unknown conflict policy",
+			modify:  func(cfg *Config) { cfg.Consumer.ConflictPolicy = "overwite" },
+			wantErr: ErrUnknownConflictPolicy,
+		},
+		{
+			name:    "unknown SASL mechanism",
+			modify:  func(cfg *Config) { cfg.SASL = SASLConfig{Mechanism: "gssapi", Username: "user"} },
+			wantErr: ErrUnknownSASLMechanism,
+		},
+		{
+			name:    "SASL without username",
+			modify:  func(cfg *Config) { cfg.SASL = SASLConfig{Mechanism: SASLPlain} },
+			wantErr: ErrInvalidSASL,
+		},
+		{
+			name:    "TLS files without TLS",
+			modify:  func(cfg *Config) { cfg.TLS = TLSConfig{CAFile: "ca.pem"} },
+			wantErr: ErrInvalidTLS,
+		},
+		{
+			name:    "missing CA file",
+			modify:  func(cfg *Config) { cfg.TLS = TLSConfig{Enabled: true, CAFile: "/nonexistent/ca.pem"} },
+			wantErr: ErrInvalidTLS,
+		},
+		{
+			name:    "client certificate without key",
+			modify:  func(cfg *Config) { cfg.TLS = TLSConfig{Enabled: true, CertFile: "client.pem"} },
+			wantErr: ErrInvalidTLS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestConfig_Validate_ReportsAllErrors(t *testing.T) {
+	cfg := validConfig()
+	cfg.Brokers = nil
+	cfg.Consumer.RebalanceStrategy = "sticky"
+
+	err := cfg.Validate()
+
+	require.ErrorIs(t, err, ErrNoBrokers)
+	require.ErrorIs(t, err, ErrUnknownRebalanceStrategy)
+}
+
+func TestNewConsumer_AppliesConfig(t *testing.T) {
+	cfg := validConfig()
+	cfg.Consumer.AutoOffsetReset = OffsetResetLatest
+	cfg.Consumer.MinBytes = 10
+	cfg.Consumer.MaxWait = 250
+	cfg.Consumer.HeartbeatInterval = 1000
+	cfg.Consumer.SessionTimeout = 6000
+	require.NoError(t, cfg.Validate())
+	dialer, err := NewDialer(cfg)
+	require.NoError(t, err)
+
+	reader := NewConsumer(cfg, cfg.InputTopics()[0], dialer)
+	defer reader.Close()
+	readerConfig := reader.reader.Config()
+
+	assert.Equal(t, "orders", readerConfig.Topic)
+	assert.Equal(t, "orders-group", readerConfig.GroupID)
+	assert.Equal(t, kafka.LastOffset, readerConfig.StartOffset)
+	assert.Equal(t, 10, readerConfig.MinBytes)
+	assert.Equal(t, defaultMaxBytes, readerConfig.MaxBytes)
+	assert.Equal(t, 250*time.Millisecond, readerConfig.MaxWait)
+	assert.Equal(t, time.Second, readerConfig.HeartbeatInterval)
+	assert.Equal(t, 6*time.Second, readerConfig.SessionTimeout)
+	assert.Equal(t, []kafka.GroupBalancer{kafka.RangeGroupBalancer{}}, readerConfig.GroupBalancers)
+	assert.Same(t, dialer, readerConfig.Dialer)
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// groupLag returns number of messages after offset committed by consumer group by partition of topic.
+// Partition without committed offset lags by all its messages.
+func groupLag(ctx context.Context, client *kafka.Client, groupID, topic string) (map[int]int64, error) {
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+	starts, err := listOffsets(ctx, client, topic, partitions, kafka.FirstOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := listOffsets(ctx, client, topic, partitions, kafka.LastOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err == nil {
+		err = resp.Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch offsets of group %q: %w", groupID, err)
+	}
+
+	committed := make(map[int]int64, len(partitions))
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("fetch offset of partition %d: %w", partition.Partition, partition.Error)
+		}
+		committed[partition.Partition] = partition.CommittedOffset
+	}
+	lag := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		// Broker reports -1 for partition without committed offset
+		offset, ok := committed[partition]
+		if !ok || offset < 0 {
+			offset = starts[partition].FirstOffset
+		}
+		lag[partition] = max(ends[partition].LastOffset-offset, 0)
+	}
+	return lag, nil
+}
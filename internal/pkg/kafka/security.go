@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms supported by broker connections.
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+var (
+	ErrUnknownSASLMechanism = errors.New("unknown SASL mechanism")
+	ErrInvalidTLS           = errors.New("invalid TLS config")
+	ErrInvalidSASL          = errors.New("invalid SASL config")
+)
+
+type TLSConfig struct {
+	// Enabled turns TLS on. Without CA file, server certificate is verified with system roots.
+	Enabled bool `mapstructure:"enabled"`
+	// CAFile is a PEM file with certificates of authorities which sign broker certificates.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile are PEM files of client certificate, used if broker authenticates clients by TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+type SASLConfig struct {
+	// Mechanism is one of: plain, scram-sha-256, scram-sha-512. Empty mechanism disables SASL.
+	Mechanism string `mapstructure:"mechanism"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+}
+
+// build returns TLS config, or nil if TLS is disabled. Certificate files are read, so they are validated too.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	if !c.Enabled {
+		if c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" {
+			return nil, fmt.Errorf("%w: certificate files are set, but TLS is disabled", ErrInvalidTLS)
+		}
+		return nil, nil //nolint:nilnil
+	}
+
+	res := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read CA file: %w", ErrInvalidTLS, err)
+		}
+		res.RootCAs = x509.NewCertPool()
+		if !res.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates in CA file %s", ErrInvalidTLS, c.CAFile)
+		}
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("%w: client certificate and key must be set together", ErrInvalidTLS)
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: load client certificate: %w", ErrInvalidTLS, err)
+		}
+		res.Certificates = []tls.Certificate{cert}
+	}
+	return res, nil
+}
+
+// build returns SASL mechanism, or nil if SASL is disabled.
+func (c *SASLConfig) build() (sasl.Mechanism, error) { //nolint:ireturn
+	if c.Mechanism == "" {
+		return nil, nil //nolint:nilnil
+	}
+	if c.Username == "" {
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidSASL)
+	}
+
+	switch c.Mechanism {
+	case SASLPlain:
+		return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSASLMechanism, c.Mechanism)
+	}
+}
+
+// NewDialer creates dialer of broker connections secured according to config.
+func NewDialer(config *Config) (*kafka.Dialer, error) {
+	tlsConfig, err := config.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := config.SASL.build()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:       dialTimeout,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}
+	return dialer, nil
+}
+
+// newTransport creates transport of writer with the same security as dialer.
+func newTransport(dialer *kafka.Dialer) *kafka.Transport {
+	return &kafka.Transport{
+		TLS:  dialer.TLS,
+		SASL: dialer.SASLMechanism,
+	}
+}
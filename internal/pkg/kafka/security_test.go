@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes self-signed certificate and its key to PEM files in dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestNewDialer_TLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+	cfg := validConfig()
+	cfg.TLS = TLSConfig{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}
+
+	dialer, err := NewDialer(cfg)
+
+	require.NoError(t, err)
+	require.NotNil(t, dialer.TLS)
+	assert.NotNil(t, dialer.TLS.RootCAs)
+	assert.Len(t, dialer.TLS.Certificates, 1)
+	assert.Nil(t, dialer.SASLMechanism)
+	assert.Equal(t, dialer.TLS, newTransport(dialer).TLS)
+}
+
+func TestNewDialer_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	cfg := validConfig()
+	cfg.TLS = TLSConfig{Enabled: true, CAFile: caFile}
+
+	_, err := NewDialer(cfg)
+
+	require.ErrorIs(t, err, ErrInvalidTLS)
+}
+
+func TestNewDialer_SASL(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		name      string
+	}{
+		{mechanism: SASLPlain, name: "PLAIN"},
+		{mechanism: SASLScramSHA256, name: "SCRAM-SHA-256"},
+		{mechanism: SASLScramSHA512, name: "SCRAM-SHA-512"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.SASL = SASLConfig{Mechanism: tt.mechanism, Username: "user", Password: "secret"}
+
+			dialer, err := NewDialer(cfg)
+
+			require.NoError(t, err)
+			require.NotNil(t, dialer.SASLMechanism)
+			assert.Equal(t, tt.name, dialer.SASLMechanism.Name())
+			assert.Nil(t, dialer.TLS)
+			assert.Equal(t, dialer.SASLMechanism, newTransport(dialer).SASL)
+		})
+	}
+}
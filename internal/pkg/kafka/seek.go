@@ -0,0 +1,182 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	ErrUnknownPartition     = errors.New("unknown partition")
+	ErrPartitionNotAssigned = errors.New("partition is not assigned to consumer")
+)
+
+// SeekPosition is a position consumer group is moved to.
+type SeekPosition struct {
+	// Partition limits seek to one partition. Nil means all partitions of topic.
+	Partition *int
+	// Offset of the next message to consume. It is used if Time is zero.
+	Offset int64
+	// Time moves to the first message produced at or after it.
+	// Partition without such messages is moved to its end.
+	Time time.Time
+}
+
+func newClient(config *Config, dialer *kafka.Dialer) *kafka.Client {
+	return &kafka.Client{
+		Addr:      kafka.TCP(config.Brokers...),
+		Timeout:   dialTimeout,
+		Transport: newTransport(dialer),
+	}
+}
+
+// resolveOffsets returns offsets of the next messages to consume by partition for position.
+func resolveOffsets(ctx context.Context, client *kafka.Client, topic string, pos SeekPosition) (map[int]int64, error) {
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+	if pos.Partition != nil {
+		if !contains(partitions, *pos.Partition) {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownPartition, *pos.Partition)
+		}
+		partitions = []int{*pos.Partition}
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	if pos.Time.IsZero() {
+		for _, partition := range partitions {
+			offsets[partition] = pos.Offset
+		}
+		return offsets, nil
+	}
+
+	// Broker reports no offset for partition without messages after time, such partition is moved to its end
+	ends, err := listOffsets(ctx, client, topic, partitions, func(partition int) kafka.OffsetRequest {
+		return kafka.LastOffsetOf(partition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	found, err := listOffsets(ctx, client, topic, partitions, func(partition int) kafka.OffsetRequest {
+		return kafka.TimeOffsetOf(partition, pos.Time)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, partition := range partitions {
+		offsets[partition] = ends[partition].LastOffset
+		for offset := range found[partition].Offsets {
+			if offset >= 0 {
+				offsets[partition] = offset
+			}
+		}
+	}
+	return offsets, nil
+}
+
+func topicPartitions(ctx context.Context, client *kafka.Client, topic string) ([]int, error) {
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("get metadata of topic %q: %w", topic, err)
+	}
+	for _, t := range metadata.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("get metadata of topic %q: %w", topic, t.Error)
+		}
+		partitions := make([]int, len(t.Partitions))
+		for i, partition := range t.Partitions {
+			partitions[i] = partition.ID
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("get metadata of topic %q: %w", topic, kafka.UnknownTopicOrPartition)
+}
+
+func listOffsets(
+	ctx context.Context,
+	client *kafka.Client,
+	topic string,
+	partitions []int,
+	request func(partition int) kafka.OffsetRequest,
+) (map[int]kafka.PartitionOffsets, error) {
+	requests := make([]kafka.OffsetRequest, len(partitions))
+	for i, partition := range partitions {
+		requests[i] = request(partition)
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("list offsets of topic %q: %w", topic, err)
+	}
+
+	res := make(map[int]kafka.PartitionOffsets, len(partitions))
+	for _, offsets := range resp.Topics[topic] {
+		if offsets.Error != nil {
+			return nil, fmt.Errorf("list offsets of partition %d: %w", offsets.Partition, offsets.Error)
+		}
+		res[offsets.Partition] = offsets
+	}
+	return res, nil
+}
+
+// commitGroupOffsets commits offsets of consumer group of topic.
+// Broker accepts it only if group has no active members, so consumers of group must leave it first.
+func commitGroupOffsets(
+	ctx context.Context,
+	client *kafka.Client,
+	groupID, topic string,
+	offsets map[int]int64,
+) error {
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("commit offsets of group %q: %w", groupID, err)
+	}
+	var errs []error
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			errs = append(errs, fmt.Errorf("commit offset of partition %d: %w", partition.Partition, partition.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// groupMembers returns number of active members of consumer group.
+func groupMembers(ctx context.Context, client *kafka.Client, groupID string) (int, error) {
+	resp, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+	if err != nil {
+		return 0, fmt.Errorf("describe group %q: %w", groupID, err)
+	}
+	for _, group := range resp.Groups {
+		if group.GroupID != groupID {
+			continue
+		}
+		if group.Error != nil {
+			return 0, fmt.Errorf("describe group %q: %w", groupID, group.Error)
+		}
+		return len(group.Members), nil
+	}
+	return 0, nil
+}
+
+func contains(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"errors"
+	"time"
+)
+
+// Statuses of consumer reported by State.
+const (
+	StatusRunning = "running"
+	StatusPaused  = "paused"
+	StatusStopped = "stopped"
+)
+
+var (
+	ErrUnknownTopic    = errors.New("unknown topic")
+	ErrNotIdle         = errors.New("consumer is not paused or has messages in progress")
+	ErrSeekUnsupported = errors.New("message source doesn't support seek")
+)
+
+// State describes consumer of topic.
+type State struct {
+	Topic string `json:"topic"`
+	// Status is one of: running, paused, stopped.
+	Status string `json:"status"`
+	// InFlight is a number of fetched messages which are not committed yet. Paused consumer is idle when it is 0.
+	InFlight int `json:"in_flight"`
+	// LastOffsets are offsets of the last committed messages by partition.
+	LastOffsets  map[int]int64 `json:"last_offsets,omitempty"`
+	LastCommitAt *time.Time    `json:"last_commit_at,omitempty"`
+	LastError    string        `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time    `json:"last_error_at,omitempty"`
+}
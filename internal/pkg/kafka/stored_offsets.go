@@ -0,0 +1,294 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wb-L0-task/internal/pkg/backoff"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+//nolint:gochecknoglobals
+var storedOffsetsBackoff = backoff.Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// StoredOffsets is an external storage of offsets of the next messages to consume by partition.
+type StoredOffsets interface {
+	// Load returns offsets for partitions of topic. Partitions without stored offset are omitted.
+	Load(ctx context.Context, topic string, partitions []int) (map[int]int64, error)
+	// Reset overwrites offsets of partitions of topic.
+	Reset(ctx context.Context, topic string, offsets map[int]int64) error
+}
+
+// StoredOffsetReader consumes topic as a member of consumer group like kafka.Reader,
+// but every assigned partition starts from offset loaded from external storage.
+// Offsets committed to broker are advisory: they are used only for partitions without stored offset.
+type StoredOffsetReader struct {
+	config *Config
+	topic  TopicConfig
+	dialer *kafka.Dialer
+	group  *kafka.ConsumerGroup
+	store  StoredOffsets
+	msgs   chan kafka.Message
+	gen    atomic.Pointer[kafka.Generation]
+	cancel context.CancelFunc
+	done   chan struct{}
+	// mu guards partitions assigned in current generation and restart signal of their readers.
+	mu       sync.Mutex
+	assigned []int
+	restart  chan struct{}
+}
+
+// NewStoredOffsetConsumer creates reader of topic which seeks to offsets loaded from store on every partition assignment.
+// Config must be validated.
+func NewStoredOffsetConsumer(
+	config *Config,
+	topic TopicConfig,
+	dialer *kafka.Dialer,
+	store StoredOffsets,
+) (*StoredOffsetReader, error) {
+	group, err := kafka.NewConsumerGroup(config.groupConfig(topic, dialer))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &StoredOffsetReader{
+		config:  config,
+		topic:   topic,
+		dialer:  dialer,
+		group:   group,
+		store:   store,
+		msgs:    make(chan kafka.Message),
+		restart: make(chan struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+// run reads assigned partitions of every generation of consumer group until reader is closed.
+func (r *StoredOffsetReader) run(ctx context.Context) {
+	defer close(r.done)
+	for {
+		gen, err := r.group.Next(ctx)
+		if err != nil {
+			return
+		}
+		r.gen.Store(gen)
+		assignments := gen.Assignments[r.topic.Name]
+		logger.Info("Kafka partitions assigned",
+			"topic", r.topic.Name, "generation", gen.ID, "partitions", len(assignments))
+		r.assign(assignments)
+		gen.Start(func(ctx context.Context) {
+			for {
+				restart := r.restartSignal()
+				r.readGeneration(ctx, assignments, restart)
+				// Partitions are read again from stored offsets only after seek
+				select {
+				case <-ctx.Done():
+					return
+				case <-restart:
+				}
+			}
+		})
+	}
+}
+
+// readGeneration reads assigned partitions from stored offsets until generation ends or restart is closed.
+func (r *StoredOffsetReader) readGeneration(
+	ctx context.Context,
+	assignments []kafka.PartitionAssignment,
+	restart <-chan struct{},
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-restart:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	partitions := make([]int, len(assignments))
+	for i, assignment := range assignments {
+		partitions[i] = assignment.ID
+	}
+	offsets, err := r.loadOffsets(ctx, partitions)
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, assignment := range assignments {
+		offset, ok := offsets[assignment.ID]
+		if !ok {
+			offset = assignment.Offset
+		}
+		wg.Add(1)
+		go func(partition int, offset int64) {
+			defer wg.Done()
+			r.readPartition(ctx, partition, offset)
+		}(assignment.ID, offset)
+	}
+	wg.Wait()
+}
+
+// loadOffsets loads stored offsets, retrying until it succeeds or generation ends.
+// Partitions are not read before offsets are loaded, otherwise processed messages would be read again.
+func (r *StoredOffsetReader) loadOffsets(ctx context.Context, partitions []int) (map[int]int64, error) {
+	for attempt := 0; ; attempt++ {
+		offsets, err := r.store.Load(ctx, r.topic.Name, partitions)
+		if err == nil {
+			return offsets, nil
+		}
+		delay := storedOffsetsBackoff.Delay(attempt)
+		logger.Error("Failed to load stored offsets", "topic", r.topic.Name, "err", err, "retry_in", delay)
+		if waitErr := backoff.Wait(ctx, delay); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+func (r *StoredOffsetReader) readPartition(ctx context.Context, partition int, offset int64) {
+	readerConfig := r.config.readerConfig(r.topic, r.dialer)
+	readerConfig.Partition = partition
+	reader := kafka.NewReader(readerConfig)
+	defer reader.Close() //nolint:errcheck
+
+	if err := reader.SetOffset(offset); err != nil {
+		logger.Error("Failed to seek partition", "topic", r.topic.Name, "partition", partition, "offset", offset, "err", err)
+		return
+	}
+	logger.Info("Reading partition from stored offset", "topic", r.topic.Name, "partition", partition, "offset", offset)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			logger.Error("Error while reading partition", "topic", r.topic.Name, "partition", partition, "err", err)
+			continue
+		}
+		select {
+		case r.msgs <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// FetchMessage returns next message of any assigned partition.
+// Messages of the same partition are returned in order. io.EOF is returned after reader is closed.
+func (r *StoredOffsetReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-r.done:
+		return kafka.Message{}, io.EOF
+	case msg := <-r.msgs:
+		return msg, nil
+	}
+}
+
+// CommitMessages commits offsets to broker for monitoring tools. As offsets are stored externally,
+// failed commit, e.g. after rebalance, is only logged.
+func (r *StoredOffsetReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	gen := r.gen.Load()
+	if gen == nil || len(msgs) == 0 {
+		return nil
+	}
+	if err := gen.CommitOffsets(nextOffsets(msgs)); err != nil {
+		logger.Warn("Failed to commit offsets to broker", "err", err)
+	}
+	return nil
+}
+
+// nextOffsets returns offsets of the next messages to consume by topic and partition.
+func nextOffsets(msgs []kafka.Message) map[string]map[int]int64 {
+	offsets := make(map[string]map[int]int64)
+	for _, msg := range msgs {
+		partitions, ok := offsets[msg.Topic]
+		if !ok {
+			partitions = make(map[int]int64)
+			offsets[msg.Topic] = partitions
+		}
+		if next := msg.Offset + 1; next > partitions[msg.Partition] {
+			partitions[msg.Partition] = next
+		}
+	}
+	return offsets
+}
+
+func (r *StoredOffsetReader) assign(assignments []kafka.PartitionAssignment) {
+	partitions := make([]int, len(assignments))
+	for i, assignment := range assignments {
+		partitions[i] = assignment.ID
+	}
+	r.mu.Lock()
+	r.assigned = partitions
+	r.mu.Unlock()
+}
+
+func (r *StoredOffsetReader) restartSignal() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.restart
+}
+
+// Lag returns number of messages after offset committed to broker by partition.
+// Broker offsets are committed after stored ones, so lag doesn't depend on availability of storage.
+func (r *StoredOffsetReader) Lag(ctx context.Context) (map[int]int64, error) {
+	return groupLag(ctx, newClient(r.config, r.dialer), r.topic.GroupID, r.topic.Name)
+}
+
+// Seek stores offsets of position and returns them by partition. Readers of partitions are restarted from new offsets.
+// Partitions of position must be assigned to this member of consumer group,
+// otherwise other members would overwrite stored offsets. Caller must not fetch messages while seeking.
+func (r *StoredOffsetReader) Seek(ctx context.Context, pos SeekPosition) (map[int]int64, error) {
+	offsets, err := resolveOffsets(ctx, newClient(r.config, r.dialer), r.topic.Name, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for partition := range offsets {
+		if !contains(r.assigned, partition) {
+			return nil, fmt.Errorf("%w: %d", ErrPartitionNotAssigned, partition)
+		}
+	}
+	if err = r.store.Reset(ctx, r.topic.Name, offsets); err != nil {
+		return nil, err
+	}
+	if gen := r.gen.Load(); gen != nil {
+		if commitErr := gen.CommitOffsets(map[string]map[int]int64{r.topic.Name: offsets}); commitErr != nil {
+			logger.Warn("Failed to commit offsets to broker", "err", commitErr)
+		}
+	}
+	close(r.restart)
+	r.restart = make(chan struct{})
+	return offsets, nil
+}
+
+// Close leaves consumer group and stops reading partitions.
+func (r *StoredOffsetReader) Close() error {
+	r.cancel()
+	err := r.group.Close()
+	<-r.done
+	return err
+}
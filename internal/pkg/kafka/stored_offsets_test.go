@@ -0,0 +1,28 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextOffsets(t *testing.T) {
+	offsets := nextOffsets([]kafka.Message{
+		{Topic: "orders", Partition: 0, Offset: 7},
+		{Topic: "orders", Partition: 0, Offset: 5},
+		{Topic: "orders", Partition: 1, Offset: 0},
+		{Topic: "updates", Partition: 0, Offset: 2},
+	})
+
+	assert.Equal(t, map[string]map[int]int64{
+		"orders":  {0: 8, 1: 1},
+		"updates": {0: 3},
+	}, offsets)
+}
+
+func TestOffsetStorage_Validate(t *testing.T) {
+	assert.NoError(t, OffsetStorageKafka.Validate())
+	assert.NoError(t, OffsetStoragePostgres.Validate())
+	assert.ErrorIs(t, OffsetStorage("redis").Validate(), ErrUnknownOffsetStorage)
+}
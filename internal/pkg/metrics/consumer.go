@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "order"
+	subsystem = "consumer"
+	// outcomeSaved is an outcome of successfully saved order reported by order service.
+	outcomeSaved = "saved"
+)
+
+// Consumer collects metrics of Kafka consumer by topic and partition.
+// Time since the last successful save is time() - order_consumer_last_save_timestamp_seconds.
+type Consumer struct {
+	lag                *prometheus.GaugeVec
+	processed          *prometheus.CounterVec
+	processingDuration *prometheus.HistogramVec
+	saveDuration       *prometheus.HistogramVec
+	lastSave           *prometheus.GaugeVec
+}
+
+// NewConsumer creates consumer metrics and registers them in registerer.
+func NewConsumer(registerer prometheus.Registerer) *Consumer {
+	c := &Consumer{
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "lag_messages",
+			Help:      "Number of messages of partition after the last committed one.",
+		}, []string{"topic", "partition"}),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "messages_processed_total",
+			Help:      "Number of processed and committed messages by outcome.",
+		}, []string{"topic", "partition", "outcome"}),
+		processingDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "processing_duration_seconds",
+			Help:      "Time from fetch of message until its offset is committed, including retries.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"topic", "partition"}),
+		saveDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "save_duration_seconds",
+			Help:      "Time of one attempt to decode, validate and save order by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"topic", "partition", "outcome"}),
+		lastSave: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "last_save_timestamp_seconds",
+			Help:      "Unix time of the last successfully saved order.",
+		}, []string{"topic", "partition"}),
+	}
+	registerer.MustRegister(c.lag, c.processed, c.processingDuration, c.saveDuration, c.lastSave)
+	return c
+}
+
+// ObserveMessage counts committed message by outcome and observes its processing time.
+func (c *Consumer) ObserveMessage(topic string, partition int, outcome string, elapsed time.Duration) {
+	p := strconv.Itoa(partition)
+	c.processed.WithLabelValues(topic, p, outcome).Inc()
+	c.processingDuration.WithLabelValues(topic, p).Observe(elapsed.Seconds())
+}
+
+// SetLag sets lag of partition.
+func (c *Consumer) SetLag(topic string, partition int, lag int64) {
+	c.lag.WithLabelValues(topic, strconv.Itoa(partition)).Set(float64(lag))
+}
+
+// ObserveSave observes save time by outcome. Successful save updates time of the last save of partition.
+func (c *Consumer) ObserveSave(topic string, partition int, outcome string, elapsed time.Duration) {
+	p := strconv.Itoa(partition)
+	c.saveDuration.WithLabelValues(topic, p, outcome).Observe(elapsed.Seconds())
+	if outcome == outcomeSaved {
+		c.lastSave.WithLabelValues(topic, p).SetToCurrentTime()
+	}
+}
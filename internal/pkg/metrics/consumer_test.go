@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	c := NewConsumer(registry)
+
+	c.ObserveMessage("orders", 1, "saved", 20*time.Millisecond)
+	c.ObserveMessage("orders", 1, "broken", time.Millisecond)
+	c.ObserveMessage("orders", 1, "saved", 10*time.Millisecond)
+	c.SetLag("orders", 1, 42)
+	c.ObserveSave("orders", 1, "invalid", time.Millisecond)
+
+	assert.InDelta(t, 2, testutil.ToFloat64(c.processed.WithLabelValues("orders", "1", "saved")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(c.processed.WithLabelValues("orders", "1", "broken")), 0)
+	assert.InDelta(t, 42, testutil.ToFloat64(c.lag.WithLabelValues("orders", "1")), 0)
+	assert.Equal(t, 1, testutil.CollectAndCount(c.processingDuration))
+	assert.Equal(t, 0, testutil.CollectAndCount(c.lastSave), "failed save doesn't update time of the last save")
+
+	before := time.Now()
+	c.ObserveSave("orders", 1, "saved", time.Millisecond)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(c.lastSave.WithLabelValues("orders", "1")), float64(before.Unix()))
+	assert.Equal(t, 2, testutil.CollectAndCount(c.saveDuration))
+}
+
+func TestConsumer_Lint(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	c := NewConsumer(registry)
+	c.ObserveMessage("orders", 0, "saved", time.Millisecond)
+	c.SetLag("orders", 0, 0)
+	c.ObserveSave("orders", 0, "saved", time.Millisecond)
+
+	problems, err := testutil.GatherAndLint(registry)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error class prefixes and codes of failures which may disappear on retry or are caused by stored data.
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	classConnectionException  = "08"
+	classInsufficientResource = "53"
+	classDataException        = "22"
+	classIntegrityViolation   = "23"
+	codeSerializationFailure  = "40001"
+	codeDeadlockDetected      = "40P01"
+	codeLockNotAvailable      = "55P03"
+	codeQueryCanceled         = "57014"
+	codeAdminShutdown         = "57P01"
+	codeCrashShutdown         = "57P02"
+	codeCannotConnectNow      = "57P03"
+)
+
+// IsTransient reports whether operation failed because of temporary database or network problem,
+// so it may succeed if retried. Cancellation of ctx is not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case codeSerializationFailure, codeDeadlockDetected, codeLockNotAvailable, codeQueryCanceled,
+			codeAdminShutdown, codeCrashShutdown, codeCannotConnectNow:
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, classConnectionException) ||
+			strings.HasPrefix(pgErr.Code, classInsufficientResource)
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		pgconn.Timeout(err) ||
+		pgconn.SafeToRetry(err)
+}
+
+// IsDataError reports whether operation failed because stored data is invalid, e.g. value is too long
+// or violates constraint, so it fails again if retried.
+func IsDataError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return strings.HasPrefix(pgErr.Code, classDataException) || strings.HasPrefix(pgErr.Code, classIntegrityViolation)
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wb-L0-task/internal/pkg/backoff"
+	"wb-L0-task/internal/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//nolint:gochecknoglobals
+var listenerBackoff = backoff.Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Listener receives notifications from postgres channel and passes their payload to handler.
+// Listening connection is reestablished automatically when it drops.
+// Notifications sent while connection is lost are not delivered, so resync is called after every reconnect.
+type Listener struct {
+	pool      *pgxpool.Pool
+	channel   string
+	handler   func(ctx context.Context, payload string)
+	resync    func(ctx context.Context)
+	connected bool
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewListener creates listener of channel. Resync may be nil if missed notifications don't matter.
+func NewListener(
+	pool *pgxpool.Pool,
+	channel string,
+	handler func(ctx context.Context, payload string),
+	resync func(ctx context.Context),
+) *Listener {
+	return &Listener{
+		pool:    pool,
+		channel: channel,
+		handler: handler,
+		resync:  resync,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run listens channel until ctx is done or Shutdown is called.
+func (l *Listener) Run(ctx context.Context) {
+	defer close(l.done)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	logger.Info("Starting postgres listener", "channel", l.channel)
+	for attempt := 0; ; attempt++ {
+		listening, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			logger.Info("Postgres listener stopped", "channel", l.channel)
+			return
+		}
+		if listening {
+			attempt = 0
+		}
+
+		delay := listenerBackoff.Delay(attempt)
+		logger.Error("Postgres listener connection lost", "channel", l.channel, "err", err, "retry_in", delay)
+		if backoff.Wait(ctx, delay) != nil {
+			logger.Info("Postgres listener stopped", "channel", l.channel)
+			return
+		}
+	}
+}
+
+// Shutdown stops listening and waits until listening connection is closed.
+func (l *Listener) Shutdown() {
+	logger.Info("Shutting down postgres listener", "channel", l.channel)
+	close(l.stop)
+	<-l.done
+}
+
+// listen holds dedicated connection and handles notifications until error.
+// Returned bool reports whether LISTEN succeeded, so reconnect backoff can be reset.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	// Connection with active LISTEN must not be returned to pool
+	conn := pooled.Hijack()
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		_ = conn.Close(closeCtx)
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return false, err
+	}
+	logger.Info("Postgres listener connected", "channel", l.channel)
+	// Resync runs after LISTEN, so changes made during resync are notified
+	if l.connected && l.resync != nil {
+		logger.Info("Postgres listener reconnected, resync missed notifications", "channel", l.channel)
+		l.resync(ctx)
+	}
+	l.connected = true
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return true, nil
+			}
+			return true, err
+		}
+		l.handler(ctx, notification.Payload)
+	}
+}
@@ -0,0 +1,90 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+var ErrSchemaNotFound = errors.New("schema not found")
+
+type Config struct {
+	// URL of Confluent compatible schema registry. Empty URL disables Avro messages.
+	URL string `mapstructure:"url"`
+	// Timeout limits one request to registry in milliseconds.
+	Timeout int `mapstructure:"timeout_ms"`
+}
+
+// Client reads schemas from Confluent compatible schema registry.
+// Schemas are immutable by id, so every schema is requested only once.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	mu      sync.RWMutex
+	schemas map[int]string
+}
+
+func New(cfg *Config) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		http:    &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Millisecond},
+		schemas: make(map[int]string),
+	}
+}
+
+// Schema returns schema registered with id.
+// Unknown id is reported with ErrSchemaNotFound, other errors may be temporary.
+func (c *Client) Schema(ctx context.Context, id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := c.fetchSchema(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+func (c *Client) fetchSchema(ctx context.Context, id int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/schemas/ids/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return "", fmt.Errorf("create schema request: %w", err)
+	}
+	req.Header.Set("Accept", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request schema %d: %w", id, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("request schema %d: unexpected status %s", id, resp.Status) //nolint:err113
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode schema %d: %w", id, err)
+	}
+	return body.Schema, nil
+}
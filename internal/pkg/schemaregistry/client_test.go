@@ -0,0 +1,73 @@
+package schemaregistry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStandIn starts local registry which knows only schema with id 1.
+func newStandIn(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", contentType)
+		switch r.PathValue("id") {
+		case "1":
+			_, _ = w.Write([]byte(`{"schema":"{\"type\":\"string\"}"}`))
+		case "500":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Schema(t *testing.T) {
+	var requests atomic.Int32
+	server := newStandIn(t, &requests)
+	client := New(&Config{URL: server.URL + "/", Timeout: 1000})
+
+	schema, err := client.Schema(context.Background(), 1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"string"}`, schema)
+
+	// Schema is cached after the first request
+	schema, err = client.Schema(context.Background(), 1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"string"}`, schema)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestClient_Schema_NotFound(t *testing.T) {
+	var requests atomic.Int32
+	client := New(&Config{URL: newStandIn(t, &requests).URL, Timeout: 1000})
+
+	_, err := client.Schema(context.Background(), 2)
+
+	require.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestClient_Schema_ServerError(t *testing.T) {
+	var requests atomic.Int32
+	client := New(&Config{URL: newStandIn(t, &requests).URL, Timeout: 1000})
+
+	_, err := client.Schema(context.Background(), 500)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrSchemaNotFound)
+
+	// Failed request is not cached
+	_, err = client.Schema(context.Background(), 500)
+	require.Error(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
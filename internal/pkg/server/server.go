@@ -13,6 +13,8 @@ type Config struct {
 	HTTPWriteTimeout      int16 `mapstructure:"http_write_timeout"`
 	HTTPIdleTimeout       int16 `mapstructure:"http_idle_timeout"`
 	HTTPReadHeaderTimeout int16 `mapstructure:"http_read_header_timeout"`
+	// AdminToken is a bearer token of admin API. Empty token disables admin API.
+	AdminToken string `mapstructure:"admin_token"`
 }
 
 func New(c *Config) *http.Server {
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConsumerOffsets stores offsets of Kafka messages processed by consumer group.
+// Offsets are written in transaction of the caller, so they are stored together with processing results.
+type ConsumerOffsets struct {
+	*Repo
+	groupID string
+}
+
+func NewConsumerOffsets(db *pgxpool.Pool, trManager TrManager, c *trmpgx.CtxGetter, groupID string) *ConsumerOffsets {
+	return &ConsumerOffsets{
+		Repo:    NewRepo(db, trManager, c),
+		groupID: groupID,
+	}
+}
+
+// Load returns offsets of the next messages to consume by partition.
+// Partitions without stored offset are omitted.
+func (c *ConsumerOffsets) Load(ctx context.Context, topic string, partitions []int) (map[int]int64, error) {
+	offsets := make(map[int]int64, len(partitions))
+	err := c.trManager.Do(ctx, func(ctx context.Context) error {
+		tx := c.getter.DefaultTrOrDB(ctx, c.db)
+		rows, err := tx.Query(ctx,
+			`SELECT partition, next_offset FROM consumer_offsets
+				WHERE group_id = $1 AND topic = $2 AND partition = ANY($3)`,
+			c.groupID, topic, partitions,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to load consumer offsets: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var partition int
+			var offset int64
+			if err = rows.Scan(&partition, &offset); err != nil {
+				return fmt.Errorf("failed to scan consumer offset: %w", err)
+			}
+			offsets[partition] = offset
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return offsets, nil
+}
+
+// Advance stores offset of the next message to consume, if it is greater than stored one.
+// It returns false if stored offset is not less, so messages before offset are already processed.
+func (c *ConsumerOffsets) Advance(ctx context.Context, topic string, partition int, offset int64) (bool, error) {
+	var advanced bool
+	err := c.trManager.Do(ctx, func(ctx context.Context) error {
+		tx := c.getter.DefaultTrOrDB(ctx, c.db)
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO consumer_offsets(group_id, topic, partition, next_offset) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (group_id, topic, partition) DO UPDATE
+				SET next_offset = EXCLUDED.next_offset, updated_at = now()
+				WHERE consumer_offsets.next_offset < EXCLUDED.next_offset`,
+			c.groupID, topic, partition, offset,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store consumer offset: %w", err)
+		}
+		advanced = tag.RowsAffected() > 0
+		return nil
+	})
+	if err != nil {
+		return false, storageError(err)
+	}
+	return advanced, nil
+}
+
+// Reset overwrites stored offsets of partitions, so consumer group reads them again from given offsets.
+func (c *ConsumerOffsets) Reset(ctx context.Context, topic string, offsets map[int]int64) error {
+	err := c.trManager.Do(ctx, func(ctx context.Context) error {
+		tx := c.getter.DefaultTrOrDB(ctx, c.db)
+		for partition, offset := range offsets {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO consumer_offsets(group_id, topic, partition, next_offset) VALUES ($1, $2, $3, $4)
+					ON CONFLICT (group_id, topic, partition) DO UPDATE
+					SET next_offset = EXCLUDED.next_offset, updated_at = now()`,
+				c.groupID, topic, partition, offset,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to reset consumer offset: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return storageError(err)
+	}
+	return nil
+}
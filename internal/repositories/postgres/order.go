@@ -4,22 +4,62 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	serviceErrors "wb-L0-task/internal/domain/errors"
 	model "wb-L0-task/internal/domain/order"
 
 	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// OrderChangesChannel is a postgres notification channel, which receives every changed order.
+// Payload is origin of change and uid of order separated by space, see OrderChange.
+const OrderChangesChannel = "order_changes"
+
+// OrderChange is a payload of OrderChangesChannel notification.
+type OrderChange struct {
+	// Origin identifies repository which changed order. It is empty if sender doesn't report it.
+	Origin string
+	UID    string
+}
+
+// ParseOrderChange parses payload of OrderChangesChannel notification.
+func ParseOrderChange(payload string) OrderChange {
+	origin, uid, ok := strings.Cut(payload, " ")
+	if !ok {
+		return OrderChange{UID: payload}
+	}
+	return OrderChange{Origin: origin, UID: uid}
+}
+
 type Order struct {
 	*Repo
+	origin string
 }
 
 func NewOrder(db *pgxpool.Pool, trManager TrManager, c *trmpgx.CtxGetter) *Order {
 	return &Order{
-		Repo: NewRepo(db, trManager, c),
+		Repo:   NewRepo(db, trManager, c),
+		origin: uuid.NewString(),
+	}
+}
+
+// Origin returns origin of changes made by this repository, which is sent with their notifications.
+func (o *Order) Origin() string {
+	return o.origin
+}
+
+// notifyChange sends notification about changed order, which listeners receive only after transaction commit.
+func (o *Order) notifyChange(ctx context.Context, tx trmpgx.Tr, orderUID string) error {
+	_, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", OrderChangesChannel, o.origin+" "+orderUID)
+	if err != nil {
+		return fmt.Errorf("failed to notify order change: %w", err)
 	}
+	return nil
 }
 
 func (o *Order) GetById(ctx context.Context, orderUID string) (*model.Order, error) {
@@ -38,6 +78,7 @@ func (o *Order) GetById(ctx context.Context, orderUID string) (*model.Order, err
 			&result.StockManagementId,
 			&result.OutOfFailureShard,
 			&result.DateCreated,
+			&result.Version,
 		)
 		if err != nil {
 			return err
@@ -76,7 +117,7 @@ func (o *Order) GetById(ctx context.Context, orderUID string) (*model.Order, err
 			return err
 		}
 
-		rows, err := tx.Query(ctx, "SELECT * FROM order_items WHERE order_uid = $1", orderUID)
+		rows, err := tx.Query(ctx, "SELECT * FROM order_items WHERE order_uid = $1 ORDER BY id", orderUID)
 		if err != nil {
 			return fmt.Errorf("failed to get order items: %w", err)
 		}
@@ -107,7 +148,7 @@ func (o *Order) GetById(ctx context.Context, orderUID string) (*model.Order, err
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, storageError(err)
 	}
 	return &result, nil
 }
@@ -122,18 +163,21 @@ func (o *Order) Exists(ctx context.Context, orderUID string) (bool, error) {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to check if order exists: %w", err)
+		return false, storageError(fmt.Errorf("failed to check if order exists: %w", err))
 	}
 	return true, nil
 }
 
+// Save inserts order with all its details. If order with the same uid exists, ErrConflict is returned.
 func (o *Order) Save(ctx context.Context, order *model.Order) error {
-	err := o.trManager.Do(ctx, func(ctx context.Context) error {
+	err := o.trManager.DoWithSettings(ctx, savepoint, func(ctx context.Context) error {
 		tx := o.getter.DefaultTrOrDB(ctx, o.db)
-		_, err := tx.Exec(
+		tag, err := tx.Exec(
 			ctx,
 			`INSERT INTO orders(uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, 
-                   shardkey, sm_id, oof_shard, date_created) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+                   shardkey, sm_id, oof_shard, date_created, version)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+				ON CONFLICT (uid) DO NOTHING`,
 			order.UID,
 			order.TrackNumber,
 			order.Entry,
@@ -145,10 +189,14 @@ func (o *Order) Save(ctx context.Context, order *model.Order) error {
 			order.StockManagementId,
 			order.OutOfFailureShard,
 			order.DateCreated,
+			order.Version,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert order: %w", err)
 		}
+		if tag.RowsAffected() == 0 {
+			return serviceErrors.ErrConflict.ForEntity("order")
+		}
 
 		_, err = tx.Exec(ctx,
 			`INSERT INTO deliveries(id, order_uid, name, phone, zip, city, address, region, email)
@@ -220,27 +268,241 @@ func (o *Order) Save(ctx context.Context, order *model.Order) error {
 		if err != nil {
 			return fmt.Errorf("failed to close batch: %w", err)
 		}
+
+		return o.notifyChange(ctx, tx, order.UID)
+	})
+	if err != nil {
+		return storageError(err)
+	}
+	return nil
+}
+
+// Replace overwrites stored order with all its details in one transaction.
+func (o *Order) Replace(ctx context.Context, order *model.Order) error {
+	err := o.trManager.DoWithSettings(ctx, savepoint, func(ctx context.Context) error {
+		tx := o.getter.DefaultTrOrDB(ctx, o.db)
+		// Details are removed by cascade
+		if _, err := tx.Exec(ctx, "DELETE FROM orders WHERE uid = $1", order.UID); err != nil {
+			return fmt.Errorf("failed to delete order: %w", err)
+		}
+		return o.Save(ctx, order)
+	})
+	if err != nil {
+		return storageError(err)
+	}
+	return nil
+}
+
+// Update applies update to stored order with all its details in one transaction.
+// Update is applied only if its version is greater than stored one, otherwise ErrNotModified is returned
+// for the same version and ErrOutdated for the lower one. Missing order or item is reported with ErrNotFound.
+func (o *Order) Update(ctx context.Context, update *model.Update) error {
+	err := o.trManager.DoWithSettings(ctx, savepoint, func(ctx context.Context) error {
+		tx := o.getter.DefaultTrOrDB(ctx, o.db)
+		tag, err := tx.Exec(ctx, "UPDATE orders SET version = $2 WHERE uid = $1 AND version < $2",
+			update.UID, update.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update order version: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return o.versionError(ctx, tx, update)
+		}
+
+		if update.Delivery != nil {
+			d := update.Delivery
+			_, err = tx.Exec(ctx,
+				`UPDATE deliveries SET name = $2, phone = $3, zip = $4, city = $5, address = $6, region = $7, email = $8
+					WHERE order_uid = $1`,
+				update.UID, d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update delivery: %w", err)
+			}
+		}
+
+		if update.Payment != nil {
+			p := update.Payment
+			_, err = tx.Exec(ctx,
+				`UPDATE payments SET transaction = $2, request_id = $3, currency = $4, provider = $5, amount = $6,
+					payment_dt = $7, bank = $8, delivery_cost = $9, goods_total = $10, custom_fee = $11
+					WHERE order_uid = $1`,
+				update.UID, p.TransactionID, p.RequestID, p.Currency, p.Provider, p.Amount,
+				p.PaymentDT, p.Bank, p.DeliveryCost, p.GoodsTotal, p.CustomFee,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update payment: %w", err)
+			}
+		}
+
+		for _, item := range update.Items {
+			tag, err = tx.Exec(ctx, "UPDATE order_items SET status = $3 WHERE order_uid = $1 AND chrt_id = $2",
+				update.UID, item.ChartID, item.Status)
+			if err != nil {
+				return fmt.Errorf("failed to update order item status: %w", err)
+			}
+			if tag.RowsAffected() == 0 {
+				return serviceErrors.ErrNotFound.ForEntity("order item")
+			}
+		}
+
+		return o.notifyChange(ctx, tx, update.UID)
+	})
+	if err != nil {
+		return storageError(err)
+	}
+	return nil
+}
+
+// Delete removes order, its details are removed by cascade. Missing order is reported with ErrNotFound.
+func (o *Order) Delete(ctx context.Context, orderUID string) error {
+	err := o.trManager.DoWithSettings(ctx, savepoint, func(ctx context.Context) error {
+		tx := o.getter.DefaultTrOrDB(ctx, o.db)
+		tag, err := tx.Exec(ctx, "DELETE FROM orders WHERE uid = $1", orderUID)
+		if err != nil {
+			return fmt.Errorf("failed to delete order: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return serviceErrors.ErrNotFound.ForEntity("order")
+		}
+
+		return o.notifyChange(ctx, tx, orderUID)
+	})
+	if err != nil {
+		return storageError(err)
+	}
+	return nil
+}
+
+// versionError explains why update is not applied to stored order.
+func (o *Order) versionError(ctx context.Context, tx trmpgx.Tr, update *model.Update) error {
+	var version int64
+	err := tx.QueryRow(ctx, "SELECT version FROM orders WHERE uid = $1", update.UID).Scan(&version)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return serviceErrors.ErrNotFound.ForEntity("order")
+	case err != nil:
+		return fmt.Errorf("failed to get order version: %w", err)
+	case version == update.Version:
+		return serviceErrors.ErrNotModified.ForEntity("order")
+	default:
+		return serviceErrors.ErrOutdated.ForEntity("order version")
+	}
+}
+
+// SaveBatch saves orders in one transaction using COPY, so batch costs a few round-trips regardless of its size.
+// Batch is saved entirely or not at all.
+func (o *Order) SaveBatch(ctx context.Context, orders []*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.UID
+	}
+
+	err := o.trManager.DoWithSettings(ctx, savepoint, func(ctx context.Context) error {
+		tx := o.getter.DefaultTrOrDB(ctx, o.db)
+
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"orders"},
+			[]string{"uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+				"delivery_service", "shardkey", "sm_id", "oof_shard", "date_created", "version"},
+			pgx.CopyFromSlice(len(orders), func(i int) ([]any, error) {
+				order := orders[i]
+				return []any{order.UID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+					order.CustomerID, order.DeliveryService, order.ShardKey, order.StockManagementId,
+					order.OutOfFailureShard, order.DateCreated, order.Version}, nil
+			}),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy orders: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"deliveries"},
+			[]string{"id", "order_uid", "name", "phone", "zip", "city", "address", "region", "email"},
+			pgx.CopyFromSlice(len(orders), func(i int) ([]any, error) {
+				d := orders[i].Delivery
+				return []any{uuid.New(), orders[i].UID, d.Name, d.Phone, d.Zip, d.City, d.Address,
+					d.Region, d.Email}, nil
+			}),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy deliveries: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"payments"},
+			[]string{"id", "order_uid", "transaction", "request_id", "currency", "provider", "amount",
+				"payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"},
+			pgx.CopyFromSlice(len(orders), func(i int) ([]any, error) {
+				p := orders[i].Payment
+				return []any{uuid.New(), orders[i].UID, p.TransactionID, p.RequestID, p.Currency, p.Provider,
+					p.Amount, p.PaymentDT, p.Bank, p.DeliveryCost, p.GoodsTotal, p.CustomFee}, nil
+			}),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy payments: %w", err)
+		}
+
+		var items [][]any
+		for _, order := range orders {
+			for _, item := range order.Items {
+				items = append(items, []any{order.UID, item.ChartID, item.TrackNumber, item.Price, item.RID,
+					item.Name, item.Sale, item.Size, item.TotalPrice, item.NomenclatureID, item.Brand, item.Status})
+			}
+		}
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"order_items"},
+			[]string{"order_uid", "chrt_id", "track_number", "price", "rid", "name", "sale", "size",
+				"total_price", "nm_id", "brand", "status"},
+			pgx.CopyFromRows(items),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy order_items: %w", err)
+		}
+
+		// Listeners receive notifications only after transaction commit
+		_, err = tx.Exec(ctx, "SELECT pg_notify($1, $3::text || ' ' || uid) FROM unnest($2::text[]) AS uid",
+			OrderChangesChannel, uids, o.origin)
+		if err != nil {
+			return fmt.Errorf("failed to notify order changes: %w", err)
+		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return storageError(err)
 	}
 	return nil
 }
 
-func (o *Order) GetOrders(ctx context.Context, limit int32) ([]model.Order, error) {
+// GetLatestOrders returns orders created after createdAfter with delivery, payment and items, newest first.
+// Pages are keyset based: pass the last order of previous page as after, or nil for the first page.
+// Every page is loaded with 4 queries regardless of its size.
+func (o *Order) GetLatestOrders(
+	ctx context.Context,
+	createdAfter time.Time,
+	after *model.Order,
+	limit int32,
+) ([]model.Order, error) {
 	orders := make([]model.Order, 0, limit)
 	err := o.trManager.Do(ctx, func(ctx context.Context) error {
 		tx := o.getter.DefaultTrOrDB(ctx, o.db)
+
+		var afterUID string
+		var afterCreated time.Time
+		if after != nil {
+			afterUID, afterCreated = after.UID, after.DateCreated
+		}
 		rows, err := tx.Query(
 			ctx,
-			"SELECT * FROM orders ORDER BY orders.date_created LIMIT $1",
+			`SELECT * FROM orders WHERE date_created > $1 AND ($2 = '' OR (date_created, uid) < ($3, $2))
+				ORDER BY date_created DESC, uid DESC LIMIT $4`,
+			createdAfter,
+			afterUID,
+			afterCreated,
 			limit,
 		)
-		defer rows.Close() //nolint:staticcheck
 		if err != nil {
 			return fmt.Errorf("failed to get orders: %w", err)
 		}
+		defer rows.Close()
 
 		for rows.Next() {
 			var order model.Order
@@ -256,25 +518,43 @@ func (o *Order) GetOrders(ctx context.Context, limit int32) ([]model.Order, erro
 				&order.StockManagementId,
 				&order.OutOfFailureShard,
 				&order.DateCreated,
+				&order.Version,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to scan order: %w", err)
 			}
 			orders = append(orders, order)
 		}
-		return rows.Err()
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("failed to get orders: %w", err)
+		}
+		return o.fillOrders(ctx, tx, orders)
 	})
 	if err != nil {
-		return nil, err
+		return nil, storageError(err)
 	}
 	return orders, nil
 }
 
-func (o *Order) GetOrderDelivery(ctx context.Context, orderUID string) (*model.Delivery, error) {
-	var delivery model.Delivery
-	err := o.trManager.Do(ctx, func(ctx context.Context) error {
-		tx := o.getter.DefaultTrOrDB(ctx, o.db)
-		err := tx.QueryRow(ctx, "SELECT * FROM deliveries WHERE order_uid = $1", orderUID).Scan(
+// fillOrders loads delivery, payment and items of all orders at once.
+func (o *Order) fillOrders(ctx context.Context, tx trmpgx.Tr, orders []model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	uids := make([]string, len(orders))
+	byUID := make(map[string]*model.Order, len(orders))
+	for i := range orders {
+		uids[i] = orders[i].UID
+		byUID[orders[i].UID] = &orders[i]
+	}
+
+	rows, err := tx.Query(ctx, "SELECT * FROM deliveries WHERE order_uid = ANY($1)", uids)
+	if err != nil {
+		return fmt.Errorf("failed to load deliveries: %w", err)
+	}
+	for rows.Next() {
+		var delivery model.Delivery
+		err = rows.Scan(
 			&delivery.ID,
 			&delivery.OrderUID,
 			&delivery.Name,
@@ -286,21 +566,23 @@ func (o *Order) GetOrderDelivery(ctx context.Context, orderUID string) (*model.D
 			&delivery.Email,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to get delivery: %w", err)
+			rows.Close()
+			return fmt.Errorf("failed to scan delivery: %w", err)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		byUID[delivery.OrderUID].Delivery = delivery
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to load deliveries: %w", err)
 	}
-	return &delivery, nil
-}
 
-func (o *Order) GetOrderPayment(ctx context.Context, orderUID string) (*model.Payment, error) {
-	var payment model.Payment
-	err := o.trManager.Do(ctx, func(ctx context.Context) error {
-		tx := o.getter.DefaultTrOrDB(ctx, o.db)
-		err := tx.QueryRow(ctx, "SELECT * FROM payments WHERE order_uid = $1", orderUID).Scan(
+	rows, err = tx.Query(ctx, "SELECT * FROM payments WHERE order_uid = ANY($1)", uids)
+	if err != nil {
+		return fmt.Errorf("failed to load payments: %w", err)
+	}
+	for rows.Next() {
+		var payment model.Payment
+		err = rows.Scan(
 			&payment.ID,
 			&payment.OrderUID,
 			&payment.TransactionID,
@@ -315,53 +597,43 @@ func (o *Order) GetOrderPayment(ctx context.Context, orderUID string) (*model.Pa
 			&payment.CustomFee,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to load order payment: %w", err)
+			rows.Close()
+			return fmt.Errorf("failed to scan payment: %w", err)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		byUID[payment.OrderUID].Payment = payment
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to load payments: %w", err)
 	}
-	return &payment, nil
-}
-
-func (o *Order) GetOrderItems(ctx context.Context, orderUID string) ([]model.Item, error) {
-	var items []model.Item
-	err := o.trManager.Do(ctx, func(ctx context.Context) error {
-		tx := o.getter.DefaultTrOrDB(ctx, o.db)
-		rows, err := tx.Query(ctx, "SELECT * FROM order_items WHERE order_uid = $1", orderUID)
 
-		defer rows.Close() //nolint:staticcheck
+	rows, err = tx.Query(ctx, "SELECT * FROM order_items WHERE order_uid = ANY($1) ORDER BY id", uids)
+	if err != nil {
+		return fmt.Errorf("failed to load order items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item model.Item
+		err = rows.Scan(
+			&item.ID,
+			&item.OrderUID,
+			&item.ChartID,
+			&item.TrackNumber,
+			&item.Price,
+			&item.RID,
+			&item.Name,
+			&item.Sale,
+			&item.Size,
+			&item.TotalPrice,
+			&item.NomenclatureID,
+			&item.Brand,
+			&item.Status,
+		)
 		if err != nil {
-			return fmt.Errorf("failed to load order items: %w", err)
-		}
-
-		for rows.Next() {
-			var item model.Item
-			err = rows.Scan(
-				&item.ID,
-				&item.OrderUID,
-				&item.ChartID,
-				&item.TrackNumber,
-				&item.Price,
-				&item.RID,
-				&item.Name,
-				&item.Sale,
-				&item.Size,
-				&item.TotalPrice,
-				&item.NomenclatureID,
-				&item.Brand,
-				&item.Status,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to load order item: %w", err)
-			}
-			items = append(items, item)
+			return fmt.Errorf("failed to scan order item: %w", err)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		order := byUID[item.OrderUID]
+		order.Items = append(order.Items, item)
 	}
-	return items, nil
+	return rows.Err()
 }
@@ -2,17 +2,29 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+
+	serviceErrors "wb-L0-task/internal/domain/errors"
+	postgres_pkg "wb-L0-task/internal/pkg/postgres"
 
 	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/avito-tech/go-transaction-manager/trm"
+	"github.com/avito-tech/go-transaction-manager/trm/settings"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type (
 	TrManager interface {
 		Do(ctx context.Context, fn func(ctx context.Context) error) error
+		DoWithSettings(ctx context.Context, s trm.Settings, fn func(ctx context.Context) error) error
 	}
 )
 
+// savepoint runs writes in savepoint of outer transaction, if it is started by caller.
+// Failed write is rolled back alone, so outer transaction can go on, e.g. to save orders of a batch one by one.
+var savepoint = trmpgx.MustSettings(settings.Must(settings.WithPropagation(trm.PropagationNested)))
+
 type Repo struct {
 	db        *pgxpool.Pool
 	getter    *trmpgx.CtxGetter
@@ -26,3 +38,18 @@ func NewRepo(db *pgxpool.Pool, trManager TrManager, c *trmpgx.CtxGetter) *Repo {
 		trManager: trManager,
 	}
 }
+
+// storageError marks temporary database failures with ErrUnavailable and failures caused by invalid data
+// with ErrInvalidEntity, so callers can tell them apart without knowing about pgx.
+func storageError(err error) error {
+	if errors.Is(err, serviceErrors.ErrUnavailable) {
+		return err
+	}
+	if postgres_pkg.IsTransient(err) {
+		return fmt.Errorf("%w: %w", serviceErrors.ErrUnavailable.ForEntity("storage"), err)
+	}
+	if postgres_pkg.IsDataError(err) {
+		return fmt.Errorf("%w: %w", serviceErrors.ErrInvalidEntity.ForEntity("stored data"), err)
+	}
+	return err
+}